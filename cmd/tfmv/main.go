@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/suzuki-shunsuke/slog-error/slogerr"
 	"github.com/suzuki-shunsuke/slog-util/slogutil"
 	"github.com/suzuki-shunsuke/tfmv/pkg/cli"
+	"github.com/suzuki-shunsuke/tfmv/pkg/controller"
 )
 
 var (
@@ -14,6 +16,12 @@ var (
 	date    = "" //nolint:gochecknoglobals
 )
 
+// exitCodeChangesFound is an exit code when blocks would be renamed in check mode.
+const exitCodeChangesFound = 3
+
+// exitCodeDirsSkipped is an exit code when directories are skipped in tolerant mode.
+const exitCodeDirsSkipped = 4
+
 func main() {
 	if code := core(); code != 0 {
 		os.Exit(code)
@@ -26,6 +34,7 @@ func core() int {
 		Version: version,
 	})
 	runner := cli.Runner{
+		Args:   os.Args[1:],
 		Stdin:  os.Stdin,
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
@@ -38,6 +47,12 @@ func core() int {
 	}
 	if err := runner.Run(); err != nil {
 		slogerr.WithError(logger.Logger, err).Error("tfmv failed")
+		if errors.Is(err, controller.ErrChangesFound) {
+			return exitCodeChangesFound
+		}
+		if errors.Is(err, controller.ErrDirsSkipped) {
+			return exitCodeDirsSkipped
+		}
 		return 1
 	}
 	return 0
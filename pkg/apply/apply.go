@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"path/filepath"
+	"slices"
 
 	"github.com/spf13/afero"
 	"github.com/suzuki-shunsuke/slog-error/slogerr"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"golang.org/x/sync/errgroup"
 )
 
 type Applier struct {
@@ -23,104 +26,192 @@ func New(fs afero.Fs, stderr io.Writer) *Applier {
 	}
 }
 
-func (a *Applier) Apply(logger *slog.Logger, input *domain.Input, dirs map[string]*domain.Dir) error {
-	editor := &Editor{
-		stderr: a.stderr,
-		dryRun: input.DryRun,
+// Apply renames blocks, fixes references, and generates moved blocks.
+// Files are edited in memory and written at the end.
+// Directories are processed concurrently because they don't share files.
+// Even in the recursive reference scope, subdirectories processed by themselves are excluded, so files aren't shared.
+// If input.DryRun is true, Apply outputs a unified diff to stderr instead of writing files.
+// skippedDirs is a list of directories skipped in tolerant mode.
+// Files in them are never changed even if they are in the reference scope.
+func (a *Applier) Apply(logger *slog.Logger, input *domain.Input, dirs map[string]*domain.Dir, skippedDirs []string) error {
+	tree, err := newTreeFinder(a.fs, input.Ignore, dirs, skippedDirs)
+	if err != nil {
+		return err
 	}
-	for _, dir := range dirs {
-		if err := a.handleDir(logger, editor, input, dir); err != nil {
-			return err
+	editor := &Editor{}
+	store := newFileStore(a.fs)
+	eg := &errgroup.Group{}
+	eg.SetLimit(input.Workers())
+	for _, dirPath := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[dirPath]
+		eg.Go(func() error {
+			return a.handleDir(logger.With("dir", dir.Path), editor, store, tree, input, dir)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if input.DryRun {
+		if err := a.diff(store, input.DiffColor); err != nil {
+			return fmt.Errorf("output a diff: %w", err)
 		}
+		return nil
 	}
-	return nil
+	return store.write()
 }
 
 // handleDir modifies files in a given directory.
-func (a *Applier) handleDir(logger *slog.Logger, editor *Editor, input *domain.Input, dir *domain.Dir) error {
+// Each file is renamed in one pass regardless of the number of renamed blocks in it.
+func (a *Applier) handleDir(logger *slog.Logger, editor *Editor, store *fileStore, tree *treeFinder, input *domain.Input, dir *domain.Dir) error {
 	// fix references
-	if err := a.fixRef(logger, dir, input); err != nil {
+	if err := a.fixRef(logger, store, tree, dir, input); err != nil {
 		return err
 	}
+	// change resource addresses by hcledit
+	files := map[string][]*domain.Block{}
 	for _, block := range dir.Blocks {
-		// change resource addresses by hcledit
-		// generate moved blocks
-		logger := logger.With(
-			"address", block.TFAddress,
-			"new_address", block.NewTFAddress,
-			"file", block.File,
-		)
-		if err := a.handleBlock(logger, editor, input, block); err != nil {
+		files[block.File] = append(files[block.File], block)
+	}
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		if err := a.renameBlocks(logger.With("file", path), editor, store, path, files[path]); err != nil {
 			return err
 		}
 	}
+	// generate moved blocks
+	if input.Emits(domain.EmitMoved) {
+		for _, block := range dir.Blocks {
+			if block.IsData() {
+				continue
+			}
+			logger.Debug("writing a moved block", "address", block.TFAddress, "new_address", block.NewTFAddress, "moved_file", block.MovedFile)
+			if err := a.writeMovedBlock(store, block, block.MovedFile); err != nil {
+				return fmt.Errorf("write a moved block: %w", err)
+			}
+			block.MovedBlockWritten = true
+		}
+	}
+	if input.Emits(domain.EmitStateMv) {
+		logger.Debug("writing terraform state mv commands", "file", input.StateMvFile)
+		if err := a.writeStateMv(store, dir, input.StateMvFile); err != nil {
+			return fmt.Errorf("write terraform state mv commands: %w", err)
+		}
+	}
+	if input.Emits(domain.EmitTFMigrate) {
+		logger.Debug("writing a tfmigrate migration file", "file", input.TFMigrateFile)
+		if err := a.writeTFMigrate(logger, store, dir, input.TFMigrateFile); err != nil {
+			return fmt.Errorf("write a tfmigrate migration file: %w", err)
+		}
+	}
 	return nil
 }
 
-func applyFixes(body string, blocks []*domain.Block) string {
+// applyFixes fixes references to blocks in body in one scan.
+// It records the number of fixed references to each block.
+func applyFixes(file, body string, matcher *domain.RefMatcher, blocks []*domain.Block) string {
+	body, counts := matcher.Replace(body)
 	for _, b := range blocks {
-		body = b.Fix(body)
+		if count := counts[b]; count != 0 {
+			b.AddRefFile(file, count)
+		}
 	}
 	return body
 }
 
-func (a *Applier) fixRef(logger *slog.Logger, dir *domain.Dir, input *domain.Input) error {
-	files := dir.Files
-	if len(input.Args) != 0 {
-		arr, err := afero.Glob(a.fs, filepath.Join(dir.Path, "*.tf"))
-		if err != nil {
-			return fmt.Errorf("find a file: %w", err)
+// fixRef fixes references to blocks in files of the reference scope.
+// It also records references remaining in files outside the scope.
+func (a *Applier) fixRef(logger *slog.Logger, store *fileStore, tree *treeFinder, dir *domain.Dir, input *domain.Input) error {
+	if len(dir.Blocks) == 0 {
+		return nil
+	}
+	files, err := a.refScopeFiles(tree, dir, input.RefScope)
+	if err != nil {
+		return err
+	}
+	matcher, err := domain.NewRefMatcher(dir.Blocks)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if input.RefScope != domain.RefScopeRecursive {
+		if err := a.findUnfixedRefs(logger, tree, dir, matcher, files); err != nil {
+			return err
 		}
-		files = arr
 	}
-	for _, file := range files {
-		b, err := afero.ReadFile(a.fs, file)
+	for _, path := range files {
+		f, err := store.get(path)
 		if err != nil {
-			return fmt.Errorf("read a file: %w", slogerr.With(err, "file", file))
+			return err
 		}
-		orig := string(b)
-		s := applyFixes(orig, dir.Blocks)
+		orig := string(f.content)
+		s := applyFixes(path, orig, matcher, dir.Blocks)
 		if orig == s {
 			continue
 		}
-		f, err := a.fs.Stat(file)
+		logger.Debug("fixing references", "file", path)
+		f.content = []byte(s)
+	}
+	return nil
+}
+
+// refScopeFiles returns files where references to blocks in the directory are fixed.
+func (a *Applier) refScopeFiles(tree *treeFinder, dir *domain.Dir, scope string) ([]string, error) {
+	switch scope {
+	case domain.RefScopeFile:
+		return dir.Files, nil
+	case domain.RefScopeRecursive:
+		return tree.files(dir.Path)
+	default:
+		files, err := afero.Glob(a.fs, filepath.Join(dir.Path, "*.tf"))
 		if err != nil {
-			return fmt.Errorf("get a file stat: %w", slogerr.With(err, "file", file))
-		}
-		if input.DryRun {
-			logger.Debug("[DRY RUN] fixing references", "file", file)
-		} else {
-			logger.Debug("fixing references", "file", file)
-			if err := afero.WriteFile(a.fs, file, []byte(s), f.Mode()); err != nil {
-				return fmt.Errorf("write a file: %w", slogerr.With(err, "file", file))
-			}
+			return nil, fmt.Errorf("find a file: %w", err)
 		}
+		return files, nil
 	}
-	return nil
 }
 
-// handleBlock generates a moved block and renames a block.
-func (a *Applier) handleBlock(logger *slog.Logger, editor *Editor, input *domain.Input, block *domain.Block) error {
-	// generate moved blocks
-	if !block.IsData() {
-		if input.DryRun {
-			logger.Debug("[DRY RUN] generate a moved block", "moved_file", block.MovedFile)
-		} else {
-			logger.Debug("writing a moved block", "moved_file", block.MovedFile)
-			if err := a.writeMovedBlock(block, block.MovedFile); err != nil {
-				return fmt.Errorf("write a moved block: %w", err)
+// findUnfixedRefs records references to blocks remaining in files outside the reference scope.
+// Files in the directory and subdirectories belonging to the same module are searched.
+// Original contents are read because other directories may be edited concurrently.
+func (a *Applier) findUnfixedRefs(logger *slog.Logger, tree *treeFinder, dir *domain.Dir, matcher *domain.RefMatcher, scopeFiles []string) error {
+	files, err := tree.files(dir.Path)
+	if err != nil {
+		return err
+	}
+	scope := make(map[string]struct{}, len(scopeFiles))
+	for _, path := range scopeFiles {
+		scope[filepath.Clean(path)] = struct{}{}
+	}
+	for _, path := range files {
+		if _, ok := scope[filepath.Clean(path)]; ok {
+			continue
+		}
+		b, err := afero.ReadFile(a.fs, path)
+		if err != nil {
+			return fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+		}
+		counts := matcher.Count(string(b))
+		for _, block := range dir.Blocks {
+			count := counts[block]
+			if count == 0 {
+				continue
 			}
+			logger.Warn("references to a renamed block remain outside the reference scope",
+				"file", path, "address", block.TFAddress, "num_of_references", count)
+			block.AddUnfixedRefFile(path, count)
 		}
 	}
+	return nil
+}
 
-	// rename resources
-	if err := editor.Move(logger, &MoveBlockOpt{
-		From:     block.HCLAddress,
-		To:       block.NewHCLAddress,
-		FilePath: block.File,
-		Update:   true,
-	}); err != nil {
-		return fmt.Errorf("move a block: %w", err)
+// renameBlocks renames blocks in a file.
+func (a *Applier) renameBlocks(logger *slog.Logger, editor *Editor, store *fileStore, path string, blocks []*domain.Block) error {
+	f, err := store.get(path)
+	if err != nil {
+		return err
+	}
+	b, err := editor.Rename(logger, f.content, path, blocks)
+	if err != nil {
+		return fmt.Errorf("rename blocks: %w", err)
 	}
+	f.content = b
 	return nil
 }
@@ -0,0 +1,161 @@
+package apply_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/apply"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/plan"
+)
+
+// newBenchFs returns a file system with dirs directories.
+// Each directory has a file with blocks resources and a file with references to them.
+func newBenchFs(b *testing.B, dirs, blocks int) afero.Fs {
+	b.Helper()
+	fs := afero.NewMemMapFs()
+	for i := range dirs {
+		dir := fmt.Sprintf("dir%d", i)
+		main := &strings.Builder{}
+		refs := &strings.Builder{}
+		for j := range blocks {
+			fmt.Fprintf(main, "resource \"null_resource\" \"foo-%d\" {\n  triggers = {\n    name = \"foo-%d\"\n  }\n}\n\n", j, j)
+			fmt.Fprintf(refs, "output \"foo-%d\" {\n  value = null_resource.foo-%d.id\n}\n\n", j, j)
+		}
+		if err := afero.WriteFile(fs, filepath.Join(dir, "main.tf"), []byte(main.String()), 0o644); err != nil {
+			b.Fatal(err)
+		}
+		if err := afero.WriteFile(fs, filepath.Join(dir, "outputs.tf"), []byte(refs.String()), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return fs
+}
+
+// runApply plans and applies changes to files.
+func runApply(t *testing.T, files map[string]string, input *domain.Input) (afero.Fs, error) {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dirs, _, err := plan.NewPlanner(fs).Plan(logger, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs, apply.New(fs, io.Discard).Apply(logger, input, dirs, nil) //nolint:wrapcheck
+}
+
+func BenchmarkApply(b *testing.B) {
+	logger := slog.New(slog.DiscardHandler)
+	for _, bm := range []struct {
+		dirs   int
+		blocks int
+	}{
+		{dirs: 1, blocks: 300},
+		{dirs: 10, blocks: 100},
+	} {
+		b.Run(fmt.Sprintf("dirs=%d/blocks=%d", bm.dirs, bm.blocks), func(b *testing.B) {
+			input := &domain.Input{
+				Replace:   "-/_",
+				Recursive: true,
+				MovedFile: "moved.tf",
+				DryRun:    true,
+			}
+			fs := newBenchFs(b, bm.dirs, bm.blocks)
+			for b.Loop() {
+				b.StopTimer()
+				dirs, _, err := plan.NewPlanner(fs).Plan(logger, input)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := apply.New(fs, io.Discard).Apply(logger, input, dirs, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestApplier_Apply_diff(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	logger := slog.New(slog.DiscardHandler)
+	tests := []struct {
+		name    string
+		color   bool
+		exps    []string
+		notExps []string
+	}{
+		{
+			name: "no color",
+			exps: []string{
+				"--- a/main.tf\n+++ b/main.tf\n",
+				"-resource \"null_resource\" \"foo-1\" {}\n+resource \"null_resource\" \"foo_1\" {}\n",
+				"--- /dev/null\n+++ b/moved.tf\n",
+				"+moved {\n+  from = null_resource.foo-1\n+  to   = null_resource.foo_1\n+}\n",
+			},
+			notExps: []string{"\x1b["},
+		},
+		{
+			name:  "color",
+			color: true,
+			exps: []string{
+				"\x1b[1m--- a/main.tf\x1b[0m\n\x1b[1m+++ b/main.tf\x1b[0m\n\x1b[36m@@",
+				"\x1b[31m-resource \"null_resource\" \"foo-1\" {}\x1b[0m\n\x1b[32m+resource \"null_resource\" \"foo_1\" {}\x1b[0m\n",
+				"\x1b[1m--- /dev/null\x1b[0m\n",
+				"\x1b[32m+moved {\x1b[0m\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			src := "resource \"null_resource\" \"foo-1\" {}\n\noutput \"id\" {\n  value = null_resource.foo-1.id\n}\n"
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, "main.tf", []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			input := &domain.Input{
+				Replace:   "-/_",
+				MovedFile: "moved.tf",
+				DryRun:    true,
+				DiffColor: tt.color,
+			}
+			dirs, _, err := plan.NewPlanner(fs).Plan(logger, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stderr := &strings.Builder{}
+			if err := apply.New(fs, stderr).Apply(logger, input, dirs, nil); err != nil {
+				t.Fatal(err)
+			}
+			diff := stderr.String()
+			for _, exp := range tt.exps {
+				if !strings.Contains(diff, exp) {
+					t.Fatalf("the diff doesn't include %q:\n%s", exp, diff)
+				}
+			}
+			for _, notExp := range tt.notExps {
+				if strings.Contains(diff, notExp) {
+					t.Fatalf("the diff includes %q:\n%s", notExp, diff)
+				}
+			}
+			b, err := afero.ReadFile(fs, "main.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != src {
+				t.Fatal("main.tf is changed in dry-run mode")
+			}
+		})
+	}
+}
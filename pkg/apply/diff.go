@@ -0,0 +1,63 @@
+package apply
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// diff outputs a unified diff of changed files to stderr.
+func (a *Applier) diff(store *fileStore, color bool) error {
+	for _, f := range store.changedFiles() {
+		oldLabel := "a/" + f.path
+		if !f.exists {
+			oldLabel = "/dev/null"
+		}
+		d := udiff.Unified(oldLabel, "b/"+f.path, string(f.orig), string(f.content))
+		if color {
+			d = colorize(d)
+		}
+		if _, err := fmt.Fprint(a.stderr, d); err != nil {
+			return fmt.Errorf("write a diff: %w", err)
+		}
+	}
+	return nil
+}
+
+// colorize colorizes a unified diff with ANSI escape sequences.
+func colorize(d string) string {
+	lines := strings.SplitAfter(d, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			lines[i] = wrapColor(line, colorBold)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = wrapColor(line, colorCyan)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = wrapColor(line, colorRed)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = wrapColor(line, colorGreen)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// wrapColor wraps a line with a color.
+// A trailing newline is kept outside of escape sequences.
+func wrapColor(line, color string) string {
+	body, ok := strings.CutSuffix(line, "\n")
+	if !ok {
+		return color + body + colorReset
+	}
+	return color + body + colorReset + "\n"
+}
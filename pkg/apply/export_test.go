@@ -0,0 +1,4 @@
+package apply
+
+// ShellQuote exports shellQuote for tests.
+var ShellQuote = shellQuote //nolint:gochecknoglobals
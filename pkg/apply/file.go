@@ -0,0 +1,108 @@
+package apply
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// file is a file whose content is edited in memory.
+type file struct {
+	// path is a file path.
+	path string
+	// orig is the original content.
+	orig []byte
+	// content is the edited content.
+	content []byte
+	// mode is the file mode.
+	mode os.FileMode
+	// exists is true if the file exists before editing.
+	exists bool
+}
+
+// changed returns true if the content is changed.
+func (f *file) changed() bool {
+	if !f.exists {
+		return f.content != nil
+	}
+	return !bytes.Equal(f.orig, f.content)
+}
+
+// fileStore keeps files in memory until they are written.
+// Files are read only once and written only once.
+// fileStore is safe for concurrent use, but each file must be edited by only one goroutine.
+type fileStore struct {
+	fs    afero.Fs
+	mutex sync.Mutex
+	files map[string]*file
+}
+
+func newFileStore(fs afero.Fs) *fileStore {
+	return &fileStore{
+		fs:    fs,
+		files: map[string]*file{},
+	}
+}
+
+// get returns a file.
+// If the file isn't loaded yet, get reads it.
+// If the file doesn't exist, an empty file is returned.
+func (s *fileStore) get(path string) (*file, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if f, ok := s.files[path]; ok {
+		return f, nil
+	}
+	f := &file{
+		path: path,
+		mode: filePermission,
+	}
+	stat, err := s.fs.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("get a file stat: %w", slogerr.With(err, "file", path))
+		}
+		s.files[path] = f
+		return f, nil
+	}
+	b, err := afero.ReadFile(s.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	f.orig = b
+	f.content = b
+	f.mode = stat.Mode()
+	f.exists = true
+	s.files[path] = f
+	return f, nil
+}
+
+// changedFiles returns changed files sorted by path.
+func (s *fileStore) changedFiles() []*file {
+	files := make([]*file, 0, len(s.files))
+	for _, f := range s.files {
+		if f.changed() {
+			files = append(files, f)
+		}
+	}
+	slices.SortFunc(files, func(a, b *file) int {
+		return cmp.Compare(a.path, b.path)
+	})
+	return files
+}
+
+// write writes changed files.
+func (s *fileStore) write() error {
+	for _, f := range s.changedFiles() {
+		if err := afero.WriteFile(s.fs, f.path, f.content, f.mode); err != nil {
+			return fmt.Errorf("write a file: %w", slogerr.With(err, "file", f.path))
+		}
+	}
+	return nil
+}
@@ -2,52 +2,48 @@ package apply
 
 import (
 	"fmt"
-	"io"
 	"log/slog"
+	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/minamijoyo/hcledit/editor"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
 )
 
-type Editor struct {
-	stderr io.Writer
-	dryRun bool
-}
+type Editor struct{}
 
-type MoveBlockOpt struct {
-	// From is a source address.
-	From string
-	// To is a new address.
-	To       string
-	FilePath string
-	Stdin    io.Reader
-	Stdout   io.Writer
-	// If `Update` is true, the Terraform Configuration is updated in-place.
-	Update bool
+// Rename renames blocks in src at once and returns the updated content.
+// src is parsed and formatted only once regardless of the number of blocks.
+// filePath is used only for error messages.
+func (e *Editor) Rename(logger *slog.Logger, src []byte, filePath string, blocks []*domain.Block) ([]byte, error) {
+	filter := &renameFilter{
+		labels: make(map[string][]string, len(blocks)),
+	}
+	for _, block := range blocks {
+		filter.labels[block.HCLAddress] = block.NewLabels()
+	}
+	logger.Debug("renaming blocks", "num_of_blocks", len(blocks))
+	b, err := editor.NewEditOperator(filter).Apply(src, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("rename blocks in %s: %w", filePath, err)
+	}
+	return b, nil
 }
 
-func (e *Editor) Move(logger *slog.Logger, opt *MoveBlockOpt) error {
-	filter := editor.NewBlockRenameFilter(opt.From, opt.To)
+// renameFilter is a hcledit filter to rename blocks.
+// All blocks are renamed simultaneously, so swapping names of two blocks works.
+type renameFilter struct {
+	// labels is new labels of blocks. The key is a HCL address such as "resource.aws_instance.foo".
+	labels map[string][]string
+}
 
-	if e.dryRun {
-		cl := editor.NewClient(&editor.Option{
-			InStream:  opt.Stdin,
-			OutStream: io.Discard,
-			ErrStream: e.stderr,
-		})
-		logger.Debug("[DRY RUN] moving a block")
-		if err := cl.Edit(opt.FilePath, false, filter); err != nil {
-			return fmt.Errorf("move a block in %s from %s to %s: %w", opt.FilePath, opt.From, opt.To, err)
+// Filter renames blocks in a file.
+func (f *renameFilter) Filter(file *hclwrite.File) (*hclwrite.File, error) {
+	for _, block := range file.Body().Blocks() {
+		address := strings.Join(append([]string{block.Type()}, block.Labels()...), ".")
+		if labels, ok := f.labels[address]; ok {
+			block.SetLabels(labels)
 		}
-		return nil
-	}
-	cl := editor.NewClient(&editor.Option{
-		InStream:  opt.Stdin,
-		OutStream: opt.Stdout,
-		ErrStream: e.stderr,
-	})
-	logger.Debug("moving a block")
-	if err := cl.Edit(opt.FilePath, opt.Update, filter); err != nil {
-		return fmt.Errorf("move a block in %s from %s to %s: %w", opt.FilePath, opt.From, opt.To, err)
 	}
-	return nil
+	return file, nil
 }
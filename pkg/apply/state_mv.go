@@ -0,0 +1,58 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+var scriptPermission os.FileMode = 0o755 //nolint:gochecknoglobals
+
+const stateMvHeader = `#!/bin/sh
+# This file is generated by tfmv.
+set -eu
+cd "$(dirname "$0")"
+`
+
+// writeStateMv writes `terraform state mv` commands of renamed blocks in a directory to a shell script.
+// If the script already exists, commands are appended.
+// Commands already in the script are skipped, so the script can be run safely after tfmv is re-run.
+func (a *Applier) writeStateMv(store *fileStore, dir *domain.Dir, fileName string) error {
+	f, err := store.get(filepath.Join(dir.Path, fileName))
+	if err != nil {
+		return fmt.Errorf("read a script: %w", err)
+	}
+	existing := map[string]struct{}{}
+	for line := range strings.Lines(string(f.content)) {
+		existing[strings.TrimSpace(line)] = struct{}{}
+	}
+	cmds := []string{}
+	for _, block := range dir.Blocks {
+		if block.IsData() {
+			continue
+		}
+		cmd := fmt.Sprintf("terraform state mv %s %s", shellQuote(block.TFAddress), shellQuote(block.NewTFAddress))
+		if _, ok := existing[cmd]; ok {
+			continue
+		}
+		cmds = append(cmds, cmd+"\n")
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	if !f.exists && f.content == nil {
+		f.mode = scriptPermission
+		f.content = []byte(stateMvHeader)
+	}
+	f.content = append(f.content, []byte("\n"+strings.Join(cmds, ""))...)
+	return nil
+}
+
+// shellQuote quotes a string with single quotes for POSIX shells.
+// Addresses with instance keys such as aws_instance.foo["bar"] are quoted safely.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
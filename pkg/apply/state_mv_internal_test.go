@@ -0,0 +1,140 @@
+package apply_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/apply"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+func TestApplier_Apply_stateMv(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		exp   string
+	}{
+		{
+			name: "new script",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+data "null_data_source" "bar-1" {}
+
+module "baz-1" {
+  source = "./baz"
+}
+`,
+			},
+			exp: `#!/bin/sh
+# This file is generated by tfmv.
+set -eu
+cd "$(dirname "$0")"
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+terraform state mv 'module.baz-1' 'module.baz_1'
+`,
+		},
+		{
+			name: "append to an existing script",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"tfmv_state_mv.sh": `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.a' 'null_resource.b'
+`,
+			},
+			exp: `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.a' 'null_resource.b'
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+`,
+		},
+		{
+			name: "skip commands in an existing script",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+resource "null_resource" "bar-1" {}
+`,
+				"tfmv_state_mv.sh": `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+`,
+			},
+			exp: `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+
+terraform state mv 'null_resource.bar-1' 'null_resource.bar_1'
+`,
+		},
+		{
+			name: "all commands exist",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"tfmv_state_mv.sh": `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+`,
+			},
+			exp: `#!/bin/sh
+set -eu
+
+terraform state mv 'null_resource.foo-1' 'null_resource.foo_1'
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs, err := runApply(t, tt.files, &domain.Input{
+				Replace:     "-/_",
+				Emit:        []string{domain.EmitStateMv},
+				StateMvFile: "tfmv_state_mv.sh",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := afero.ReadFile(fs, "tfmv_state_mv.sh")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, string(b))
+			}
+			if _, err := fs.Stat("moved.tf"); err == nil {
+				t.Fatal("moved.tf must not be created")
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{s: "aws_instance.foo", exp: `'aws_instance.foo'`},
+		{s: `aws_instance.foo["bar"]`, exp: `'aws_instance.foo["bar"]'`},
+		{s: `module.foo["it's"].aws_instance.bar[0]`, exp: `'module.foo["it'\''s"].aws_instance.bar[0]'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			t.Parallel()
+			if got := apply.ShellQuote(tt.s); got != tt.exp {
+				t.Fatalf("wanted %s, got %s", tt.exp, got)
+			}
+		})
+	}
+}
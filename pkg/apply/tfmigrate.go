@@ -0,0 +1,46 @@
+package apply
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// writeTFMigrate writes a tfmigrate migration file of renamed blocks in a directory.
+// A tfmigrate migration file must have only one migration block,
+// so if the file already exists, writeTFMigrate doesn't write it and records it to dir.SkippedTFMigrateFile.
+// dir is a path relative to the working directory, so tfmigrate must run in the same directory as tfmv.
+// https://github.com/minamijoyo/tfmigrate
+func (a *Applier) writeTFMigrate(logger *slog.Logger, store *fileStore, dir *domain.Dir, fileName string) error {
+	actions := []string{}
+	for _, block := range dir.Blocks {
+		if block.IsData() {
+			continue
+		}
+		actions = append(actions, fmt.Sprintf("    %s,\n", strconv.Quote("mv "+block.TFAddress+" "+block.NewTFAddress)))
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+	path := filepath.Join(dir.Path, fileName)
+	f, err := store.get(path)
+	if err != nil {
+		return fmt.Errorf("read a migration file: %w", err)
+	}
+	if f.exists || f.content != nil {
+		logger.Warn("skip writing a tfmigrate migration file because it already exists", "file", path)
+		dir.SkippedTFMigrateFile = path
+		return nil
+	}
+	f.content = []byte(fmt.Sprintf(`migration "state" "tfmv" {
+  dir = %s
+  actions = [
+%s  ]
+}
+`, strconv.Quote(filepath.ToSlash(dir.Path)), strings.Join(actions, "")))
+	return nil
+}
@@ -0,0 +1,106 @@
+package apply_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+func TestApplier_Apply_tfmigrate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		// path is a path of the migration file. The default is tfmv_tfmigrate.hcl.
+		path string
+		// exp is expected content of the migration file. If it's empty, the file must not exist.
+		exp string
+	}{
+		{
+			name: "migration file",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+data "null_data_source" "bar-1" {}
+
+module "baz-1" {
+  source = "./baz"
+}
+`,
+			},
+			exp: `migration "state" "tfmv" {
+  dir = "."
+  actions = [
+    "mv null_resource.foo-1 null_resource.foo_1",
+    "mv module.baz-1 module.baz_1",
+  ]
+}
+`,
+		},
+		{
+			name: "subdirectory",
+			files: map[string]string{
+				"foo/bar/main.tf": `resource "null_resource" "foo-1" {}
+`,
+			},
+			path: "foo/bar/tfmv_tfmigrate.hcl",
+			exp: `migration "state" "tfmv" {
+  dir = "foo/bar"
+  actions = [
+    "mv null_resource.foo-1 null_resource.foo_1",
+  ]
+}
+`,
+		},
+		{
+			name: "only data sources",
+			files: map[string]string{
+				"main.tf": `data "null_data_source" "bar-1" {}
+`,
+			},
+		},
+		{
+			name: "the migration file already exists",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"tfmv_tfmigrate.hcl": `migration "state" "test" {}
+`,
+			},
+			exp: `migration "state" "test" {}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs, err := runApply(t, tt.files, &domain.Input{
+				Replace:       "-/_",
+				Recursive:     true,
+				Emit:          []string{domain.EmitTFMigrate},
+				TFMigrateFile: "tfmv_tfmigrate.hcl",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := tt.path
+			if path == "" {
+				path = "tfmv_tfmigrate.hcl"
+			}
+			b, err := afero.ReadFile(fs, path)
+			if tt.exp == "" {
+				if err == nil {
+					t.Fatalf("%s must not be created", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, string(b))
+			}
+		})
+	}
+}
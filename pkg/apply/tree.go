@@ -0,0 +1,160 @@
+package apply
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/ignore"
+)
+
+// treeFinder finds *.tf in a directory and subdirectories belonging to the same Terraform module.
+// The following subdirectories and their subdirectories are excluded.
+//
+//   - directories ignored by .gitignore, .tfmvignore, and --ignore
+//   - directories processed by tfmv because references in them are fixed by themselves
+//   - directories skipped in tolerant mode
+//   - directories which are Terraform modules in their own right, i.e. declaring resource, data, or module blocks,
+//     because the same address there means their own block
+type treeFinder struct {
+	fs      afero.Fs
+	walker  *ignore.Walker
+	dirs    map[string]struct{}
+	skipped map[string]struct{}
+	mutex   sync.Mutex
+	// modules is a cache of whether each directory is a Terraform module.
+	modules map[string]bool
+}
+
+func newTreeFinder(afs afero.Fs, patterns []string, dirs map[string]*domain.Dir, skippedDirs []string) (*treeFinder, error) {
+	walker, err := ignore.NewWalker(afs, patterns)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	t := &treeFinder{
+		fs:      afs,
+		walker:  walker,
+		dirs:    make(map[string]struct{}, len(dirs)),
+		skipped: make(map[string]struct{}, len(skippedDirs)),
+		modules: map[string]bool{},
+	}
+	for dir := range dirs {
+		t.dirs[filepath.Clean(dir)] = struct{}{}
+	}
+	for _, dir := range skippedDirs {
+		t.skipped[filepath.Clean(dir)] = struct{}{}
+	}
+	return t, nil
+}
+
+// files returns *.tf in the directory and subdirectories belonging to the same module.
+func (t *treeFinder) files(dir string) ([]string, error) {
+	files := []string{}
+	if err := t.walker.Walk(dir, func(path string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			if strings.HasSuffix(path, ".tf") {
+				files = append(files, path)
+			}
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		ok, err := t.excluded(path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return fs.SkipDir
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("walk a directory: %w", slogerr.With(err, "dir", dir))
+	}
+	return files, nil
+}
+
+// excluded returns true if the subdirectory doesn't belong to the module of the parent directory.
+func (t *treeFinder) excluded(dir string) (bool, error) {
+	dir = filepath.Clean(dir)
+	if _, ok := t.dirs[dir]; ok {
+		return true, nil
+	}
+	if _, ok := t.skipped[dir]; ok {
+		return true, nil
+	}
+	return t.isModule(dir)
+}
+
+// isModule returns true if any *.tf in the directory declares resource, data, or module blocks.
+// Files which can't be parsed are considered to declare blocks so that they aren't changed.
+func (t *treeFinder) isModule(dir string) (bool, error) {
+	t.mutex.Lock()
+	ok, cached := t.modules[dir]
+	t.mutex.Unlock()
+	if cached {
+		return ok, nil
+	}
+	ok, err := t.declaresBlocks(dir)
+	if err != nil {
+		return false, err
+	}
+	t.mutex.Lock()
+	t.modules[dir] = ok
+	t.mutex.Unlock()
+	return ok, nil
+}
+
+func (t *treeFinder) declaresBlocks(dir string) (bool, error) {
+	files, err := afero.Glob(t.fs, filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return false, fmt.Errorf("find files: %w", err)
+	}
+	types := domain.Types()
+	for _, file := range files {
+		b, err := afero.ReadFile(t.fs, file)
+		if err != nil {
+			return false, fmt.Errorf("read a file: %w", slogerr.With(err, "file", file))
+		}
+		f, diags := hclsyntax.ParseConfig(b, file, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return true, nil
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return true, nil
+		}
+		for _, block := range body.Blocks {
+			if _, ok := types[block.Type]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ScopeFiles returns *.tf which Apply may read or change for each directory.
+// They are files in the directory and subdirectories belonging to the same module,
+// so they cover files of every reference scope.
+func (a *Applier) ScopeFiles(input *domain.Input, dirs map[string]*domain.Dir, skippedDirs []string) (map[string][]string, error) {
+	tree, err := newTreeFinder(a.fs, input.Ignore, dirs, skippedDirs)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]string, len(dirs))
+	for _, dir := range dirs {
+		arr, err := tree.files(dir.Path)
+		if err != nil {
+			return nil, err
+		}
+		files[dir.Path] = arr
+	}
+	return files, nil
+}
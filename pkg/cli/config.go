@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/config"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/urfave/cli/v3"
+)
+
+// loadConfig reads a configuration file and sets default values of flags.
+// If --config isn't set, a configuration file is searched from the current directory upward.
+// Flags set explicitly take precedence over the configuration file.
+// If no configuration file is found, loadConfig returns nil.
+func loadConfig(fs afero.Fs, cmd *cli.Command, f *Flag) (*config.Config, error) {
+	path := f.Config
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get the current directory: %w", err)
+		}
+		p, err := config.Find(fs, wd)
+		if err != nil {
+			return nil, fmt.Errorf("find a configuration file: %w", err)
+		}
+		if p == "" {
+			return nil, nil
+		}
+		path = p
+	}
+	cfg, err := config.Read(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read a configuration file: %w", err)
+	}
+	if err := applyConfig(cmd, f, cfg); err != nil {
+		return nil, fmt.Errorf("apply a configuration file: %w", slogerr.With(err, "config", path))
+	}
+	return cfg, nil
+}
+
+// renamerFlagNames is a list of flags specifying renamers.
+var renamerFlagNames = []string{"jsonnet", "starlark", "replace", "regexp", "command", "changes"} //nolint:gochecknoglobals
+
+// isRenamerSet returns true if a renamer is specified by command line options.
+func isRenamerSet(cmd *cli.Command) bool {
+	for _, name := range renamerFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRules returns rules of the configuration file.
+// If a renamer is specified by command line options, rules are ignored.
+func getRules(cfg *config.Config, cmd *cli.Command, f *Flag) ([]*domain.Rule, error) {
+	if cfg == nil || isRenamerSet(cmd) {
+		return nil, nil
+	}
+	rules, err := cfg.DomainRules(f.CommandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("read rules of the configuration file: %w", err)
+	}
+	return rules, nil
+}
+
+// applyConfig sets values of the configuration file to flags which aren't set explicitly.
+// If a renamer is specified by command line options, renamers of the configuration file are ignored
+// so that they don't take precedence over the renamer of command line options.
+func applyConfig(cmd *cli.Command, f *Flag, cfg *config.Config) error {
+	setString := func(name string, dest *string, value string) {
+		if value != "" && !cmd.IsSet(name) {
+			*dest = value
+		}
+	}
+	setBool := func(name string, dest *bool, value bool) {
+		if value && !cmd.IsSet(name) {
+			*dest = value
+		}
+	}
+	if !isRenamerSet(cmd) {
+		setString("jsonnet", &f.Jsonnet, cfg.Path(cfg.Jsonnet))
+		setString("starlark", &f.Starlark, cfg.Path(cfg.Starlark))
+		setString("replace", &f.Replace, cfg.Replace)
+		setString("regexp", &f.Regexp, cfg.Regexp)
+		setString("command", &f.Command, cfg.Command)
+		setString("changes", &f.Changes, cfg.Path(cfg.Changes))
+	}
+	setBool("command-batch", &f.CommandBatch, cfg.CommandBatch)
+	setString("include", &f.Include, cfg.Include)
+	setString("exclude", &f.Exclude, cfg.Exclude)
+	setString("moved", &f.Moved, cfg.Moved)
+	setBool("recursive", &f.Recursive, cfg.Recursive)
+	setBool("tolerant", &f.Tolerant, cfg.Tolerant)
+	setString("changed-since", &f.ChangedSince, cfg.ChangedSince)
+	setBool("dry-run", &f.DryRun, cfg.DryRun)
+	setBool("check", &f.Check, cfg.Check)
+	setString("diff-color", &f.DiffColor, cfg.DiffColor)
+	setString("log-level", &f.LogLevel, cfg.LogLevel)
+	setString("log-color", &f.LogColor, cfg.LogColor)
+	setString("format", &f.Format, cfg.Format)
+	setString("output", &f.Output, cfg.Output)
+	setString("state-mv-file", &f.StateMvFile, cfg.StateMvFile)
+	setString("tfmigrate-file", &f.TFMigrateFile, cfg.TFMigrateFile)
+	setString("ref-scope", &f.RefScope, cfg.RefScope)
+	if cfg.Parallelism != 0 && !cmd.IsSet("parallelism") {
+		f.Parallelism = cfg.Parallelism
+	}
+	if len(cfg.Emit) != 0 && !cmd.IsSet("emit") {
+		f.Emit = cfg.Emit
+	}
+	if len(cfg.RootModules) != 0 && !cmd.IsSet("root-module") {
+		f.RootModules = make([]string, len(cfg.RootModules))
+		for i, dir := range cfg.RootModules {
+			f.RootModules[i] = cfg.Path(dir)
+		}
+	}
+	if len(cfg.Ignore) != 0 && !cmd.IsSet("ignore") {
+		f.Ignore = cfg.Ignore
+	}
+	if cfg.CommandTimeout != "" && !cmd.IsSet("command-timeout") {
+		d, err := time.ParseDuration(cfg.CommandTimeout)
+		if err != nil {
+			return fmt.Errorf("parse command_timeout: %w", err)
+		}
+		f.CommandTimeout = d
+	}
+	return nil
+}
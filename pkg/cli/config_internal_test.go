@@ -0,0 +1,91 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfmv/pkg/cli"
+)
+
+func TestRunner_Run_config(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		exp    string
+	}{
+		{
+			name:   "renamer of the configuration file",
+			config: "replace: -/_\n",
+			exp:    "null_resource.foo_1",
+		},
+		{
+			name:   "a renamer of the command line overrides the configuration file",
+			config: "replace: -/_\n",
+			args:   []string{"-j", "rename.jsonnet"},
+			exp:    "null_resource.jsonnet",
+		},
+		{
+			name:   "a different renamer of the configuration file is ignored",
+			config: "jsonnet: rename.jsonnet\n",
+			args:   []string{"-r", "-/_"},
+			exp:    "null_resource.foo_1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			files := map[string]string{
+				".tfmv.yaml":     tt.config,
+				"main.tf":        `resource "null_resource" "foo-1" {}` + "\n",
+				"rename.jsonnet": `"jsonnet"` + "\n",
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			args := []string{"rename", "--config", filepath.Join(dir, ".tfmv.yaml"), "--dry-run", "--log-level", "error"}
+			for _, arg := range tt.args {
+				if strings.HasSuffix(arg, ".jsonnet") {
+					arg = filepath.Join(dir, arg)
+				}
+				args = append(args, arg)
+			}
+			stdout := &bytes.Buffer{}
+			runner := &cli.Runner{
+				Args:    append(args, filepath.Join(dir, "main.tf")),
+				Stdin:   strings.NewReader(""),
+				Stdout:  stdout,
+				Stderr:  io.Discard,
+				LDFlags: &cli.LDFlags{},
+				Logger:  slogutil.New(&slogutil.InputNew{Name: "tfmv"}),
+			}
+			if err := runner.Run(); err != nil {
+				t.Fatal(err)
+			}
+			summary := &struct {
+				Changes []struct {
+					NewAddress string `json:"new_address"`
+				} `json:"changes"`
+			}{}
+			if err := json.Unmarshal(stdout.Bytes(), summary); err != nil {
+				t.Fatal(err)
+			}
+			if len(summary.Changes) != 1 {
+				t.Fatalf("wanted 1 change, got %d", len(summary.Changes))
+			}
+			if summary.Changes[0].NewAddress != tt.exp {
+				t.Fatalf("wanted %s, got %s", tt.exp, summary.Changes[0].NewAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+package cli
+
+// AliasRename exports aliasRename for tests.
+var AliasRename = aliasRename //nolint:gochecknoglobals
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/urfave/cli/v3"
+)
+
+type Flag struct {
+	Config         string
+	Jsonnet        string
+	Starlark       string
+	Moved          string
+	LogLevel       string
+	LogColor       string
+	DiffColor      string
+	Format         string
+	Output         string
+	Out            string
+	StateMvFile    string
+	TFMigrateFile  string
+	Emit           []string
+	Ignore         []string
+	Replace        string
+	Regexp         string
+	Command        string
+	CommandTimeout time.Duration
+	Changes        string
+	ChangedSince   string
+	RootModules    []string
+	States         []string
+	Include        string
+	Exclude        string
+	Args           []string
+	Recursive      bool
+	DryRun         bool
+	Check          bool
+	CommandBatch   bool
+	Parallelism    int
+	StdinPath      string
+	RefScope       string
+	Tolerant       bool
+	MovedFD        int
+}
+
+// newFlag returns a Flag with default values.
+// Default values are set even if a subcommand doesn't have the flags,
+// so that values are always valid.
+func newFlag() *Flag {
+	return &Flag{
+		Moved:         "moved.tf",
+		LogLevel:      "info",
+		LogColor:      "auto",
+		DiffColor:     "auto",
+		Format:        "json",
+		StateMvFile:   "tfmv_state_mv.sh",
+		TFMigrateFile: "tfmv_tfmigrate.hcl",
+		RefScope:      domain.RefScopeDir,
+	}
+}
+
+// commonFlags returns flags shared by all subcommands.
+func commonFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "A configuration file path. By default, .tfmv.yaml or .tfmv.yml is searched from the current directory upward",
+			Destination: &f.Config,
+		},
+		&cli.StringFlag{
+			Name:        "log-level",
+			Usage:       "Log level",
+			Value:       "info",
+			Destination: &f.LogLevel,
+		},
+		&cli.StringFlag{
+			Name:        "log-color",
+			Usage:       `Log color. "auto", "always", "never" are available`,
+			Value:       "auto",
+			Destination: &f.LogColor,
+		},
+	}
+}
+
+// renamerFlags returns flags to specify renamers and blocks to be renamed.
+func renamerFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "replace",
+			Aliases:     []string{"r"},
+			Usage:       "Replace strings in block names. The format is <old>/<new>. e.g. -/_",
+			Destination: &f.Replace,
+		},
+		&cli.StringFlag{
+			Name:        "regexp",
+			Usage:       `Replace strings in block names by regular expression. The format is <regular expression>/<new>. e.g. '\bfoo\b/bar'`,
+			Destination: &f.Regexp,
+		},
+		&cli.StringFlag{
+			Name:        "jsonnet",
+			Aliases:     []string{"j"},
+			Usage:       "Jsonnet file path",
+			Destination: &f.Jsonnet,
+		},
+		&cli.StringFlag{
+			Name:        "starlark",
+			Usage:       "Starlark file path. The file must define a function rename(block)",
+			Destination: &f.Starlark,
+		},
+		&cli.StringFlag{
+			Name:        "command",
+			Usage:       "An external command to rename blocks. Blocks are passed to stdin as JSON and new names are read from stdout",
+			Destination: &f.Command,
+		},
+		&cli.DurationFlag{
+			Name:        "command-timeout",
+			Usage:       "A timeout of each execution of --command. If this is negative, the command never times out",
+			Value:       domain.DefaultCommandTimeout,
+			Destination: &f.CommandTimeout,
+		},
+		&cli.BoolFlag{
+			Name:        "command-batch",
+			Usage:       "Pass all blocks to --command at once as JSON Lines",
+			Destination: &f.CommandBatch,
+		},
+		&cli.StringFlag{
+			Name:        "changes",
+			Usage:       `A JSON file path of changes. The format is same as the summary of tfmv. If this is "-", changes are read from stdin`,
+			Destination: &f.Changes,
+		},
+		&cli.BoolFlag{
+			Name:        "recursive",
+			Aliases:     []string{"R"},
+			Usage:       "If this is set, tfmv finds files recursively",
+			Destination: &f.Recursive,
+		},
+		&cli.StringSliceFlag{
+			Name:        "root-module",
+			Usage:       "A root module directory. The root module and local modules called from it transitively are processed. This can be specified multiple times",
+			Destination: &f.RootModules,
+		},
+		&cli.StringFlag{
+			Name:        "changed-since",
+			Usage:       "A git ref. Only directories including *.tf changed since the ref are processed. Uncommitted changes and untracked files are included",
+			Destination: &f.ChangedSince,
+		},
+		&cli.StringSliceFlag{
+			Name:        "ignore",
+			Usage:       "A gitignore style pattern of files and directories ignored when finding files recursively. This can be specified multiple times",
+			Destination: &f.Ignore,
+		},
+		&cli.StringFlag{
+			Name:        "include",
+			Usage:       "A regular expression to filter resources. Only resources that match the regular expression are renamed",
+			Destination: &f.Include,
+		},
+		&cli.StringFlag{
+			Name:        "exclude",
+			Usage:       "A regular expression to filter resources. Only resources that don't match the regular expression are renamed",
+			Destination: &f.Exclude,
+		},
+		&cli.BoolFlag{
+			Name:        "tolerant",
+			Usage:       "Skip directories including files which can't be parsed instead of failing. Skipped directories are output in the summary and tfmv exits with the code 4",
+			Destination: &f.Tolerant,
+		},
+		&cli.StringFlag{
+			Name:        "moved",
+			Aliases:     []string{"m"},
+			Usage:       `A file name where moved blocks are written. If this is "same", the file is same with renamed resources`,
+			Value:       "moved.tf",
+			Destination: &f.Moved,
+		},
+	}
+}
+
+// summaryFlags returns flags to output a summary.
+func summaryFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       `Summary output format. "json", "jsonl", "yaml", "table", "markdown" are available`,
+			Value:       "json",
+			Destination: &f.Format,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Aliases:     []string{"o"},
+			Usage:       "A file path where a summary is written. By default, a summary is written to stdout",
+			Destination: &f.Output,
+		},
+	}
+}
+
+// applyFlags returns flags to change files.
+func applyFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Dry Run. tfmv outputs a unified diff to stderr without changing files",
+			Destination: &f.DryRun,
+		},
+		&cli.StringFlag{
+			Name:        "diff-color",
+			Usage:       `Diff color in dry-run mode. "auto", "always", "never" are available`,
+			Value:       "auto",
+			Destination: &f.DiffColor,
+		},
+	}
+}
+
+// emitFlags returns flags which decide files changed by tfmv.
+func emitFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "emit",
+			Usage:       `Outputs to migrate Terraform states. "moved", "state-mv", and "tfmigrate" are available. Multiple values can be specified by comma`,
+			Value:       []string{domain.EmitMoved},
+			Destination: &f.Emit,
+		},
+		&cli.StringFlag{
+			Name:        "state-mv-file",
+			Usage:       `A file name of shell scripts of "terraform state mv" commands`,
+			Value:       "tfmv_state_mv.sh",
+			Destination: &f.StateMvFile,
+		},
+		&cli.StringFlag{
+			Name:        "ref-scope",
+			Usage:       `A scope where references to renamed blocks are fixed. "file" (only processed files), "dir" (all *.tf in the directory), and "recursive" (all *.tf in the directory and subdirectories belonging to the same module) are available`,
+			Value:       domain.RefScopeDir,
+			Destination: &f.RefScope,
+		},
+		&cli.StringFlag{
+			Name:        "tfmigrate-file",
+			Usage:       "A file name of tfmigrate migration files",
+			Value:       "tfmv_tfmigrate.hcl",
+			Destination: &f.TFMigrateFile,
+		},
+	}
+}
+
+// parallelismFlags returns flags to process files, blocks, and directories concurrently.
+func parallelismFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "parallelism",
+			Usage:       "The maximum number of files, blocks, or directories processed concurrently. By default, the number of CPUs is used",
+			Destination: &f.Parallelism,
+		},
+	}
+}
+
+// streamFlags returns flags of stream mode.
+func streamFlags(f *Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "stdin-path",
+			Usage:       `A file path of the content read from stdin in stream mode. It's required if *.tf exist in the current directory. References in other files in the directory are reported but not fixed`,
+			Destination: &f.StdinPath,
+		},
+		&cli.IntFlag{
+			Name:        "moved-fd",
+			Usage:       "A file descriptor where moved blocks are written in stream mode. By default, moved blocks are appended to stdout",
+			Destination: &f.MovedFD,
+		},
+	}
+}
+
+// concatFlags concatenates lists of flags.
+func concatFlags(flags ...[]cli.Flag) []cli.Flag {
+	var arr []cli.Flag
+	for _, f := range flags {
+		arr = append(arr, f...)
+	}
+	return arr
+}
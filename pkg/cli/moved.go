@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/moved"
+	"github.com/urfave/cli/v3"
+)
+
+func (r *Runner) movedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "moved",
+		Usage: "Manage moved blocks",
+		Commands: []*cli.Command{
+			r.movedListCommand(),
+			r.movedPruneCommand(),
+		},
+	}
+}
+
+func (r *Runner) movedListCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "list",
+		Usage:     "List moved blocks",
+		ArgsUsage: "[dir ...]",
+		Description: `List moved blocks in *.tf in given directories.
+By default, the current directory is used.
+
+$ tfmv moved list foo bar`,
+		Flags: commonFlags(flg),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			fs := afero.NewOsFs()
+			if _, err := r.setup(cmd, fs, flg); err != nil {
+				return err
+			}
+			dirs := flg.Args
+			if len(dirs) == 0 {
+				dirs = []string{"."}
+			}
+			tw := tabwriter.NewWriter(r.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd
+			fmt.Fprintln(tw, "FILE\tFROM\tTO")
+			for _, dir := range dirs {
+				blocks, err := moved.Find(fs, dir)
+				if err != nil {
+					return fmt.Errorf("find moved blocks: %w", slogerr.With(err, "dir", dir))
+				}
+				for _, block := range blocks {
+					fmt.Fprintf(tw, "%s:%d\t%s\t%s\n", block.File, block.Line, block.From, block.To)
+				}
+			}
+			if err := tw.Flush(); err != nil {
+				return fmt.Errorf("output a table: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func (r *Runner) movedPruneCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "prune",
+		Usage:     "Remove moved blocks which are no longer needed",
+		ArgsUsage: "[dir ...]",
+		Description: `Remove moved blocks which are no longer needed from *.tf in given directories.
+By default, the current directory is used.
+A moved block is removed if the from address doesn't exist in any state and the to address exists.
+States are given by --state, which is the output of "terraform show -json" or "terraform state list".
+Removed moved blocks are output.
+
+$ terraform show -json > state.json
+$ tfmv moved prune --state state.json`,
+		Flags: concatFlags(commonFlags(flg), []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:        "state",
+				Usage:       `A file path of the output of "terraform show -json" or "terraform state list". This can be specified multiple times`,
+				Required:    true,
+				Destination: &flg.States,
+			},
+			&cli.BoolFlag{
+				Name:        "dry-run",
+				Usage:       "Dry Run. tfmv outputs moved blocks which would be removed without changing files",
+				Destination: &flg.DryRun,
+			},
+		}),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			fs := afero.NewOsFs()
+			if _, err := r.setup(cmd, fs, flg); err != nil {
+				return err
+			}
+			state, err := moved.ReadStateFiles(fs, flg.States)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			dirs := flg.Args
+			if len(dirs) == 0 {
+				dirs = []string{"."}
+			}
+			tw := tabwriter.NewWriter(r.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd
+			fmt.Fprintln(tw, "FILE\tFROM\tTO")
+			for _, dir := range dirs {
+				blocks, err := moved.Prune(fs, dir, state, flg.DryRun)
+				if err != nil {
+					return fmt.Errorf("prune moved blocks: %w", slogerr.With(err, "dir", dir))
+				}
+				for _, block := range blocks {
+					fmt.Fprintf(tw, "%s:%d\t%s\t%s\n", block.File, block.Line, block.From, block.To)
+				}
+			}
+			if err := tw.Flush(); err != nil {
+				return fmt.Errorf("output a table: %w", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/urfave/cli/v3"
+)
+
+func (r *Runner) planCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "plan",
+		Usage:     "Write planned changes to a plan file without changing Terraform files",
+		ArgsUsage: "[file ...]",
+		Description: `Write planned changes to a plan file without changing Terraform files.
+The plan file can be applied by "tfmv apply".
+Options which decide changed files such as --emit and --ref-scope are stored in the plan file.
+
+$ tfmv plan -r "-/_" --out tfmv.plan.json`,
+		Flags: concatFlags(commonFlags(flg), renamerFlags(flg), summaryFlags(flg), emitFlags(flg), parallelismFlags(flg), []cli.Flag{
+			&cli.StringFlag{
+				Name:        "out",
+				Usage:       "A plan file path",
+				Required:    true,
+				Destination: &flg.Out,
+			},
+		}),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			fs := afero.NewOsFs()
+			cfg, err := r.setup(cmd, fs, flg)
+			if err != nil {
+				return err
+			}
+			input, err := r.input(cmd, cfg, flg)
+			if err != nil {
+				return err
+			}
+			return r.newController(fs).Plan(r.Logger.Logger, input, flg.Out) //nolint:wrapcheck
+		},
+	}
+}
+
+func (r *Runner) applyCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "apply",
+		Usage:     "Apply a plan file",
+		ArgsUsage: "<plan file>",
+		Description: `Apply a plan file created by "tfmv plan".
+tfmv refuses to apply the plan if any file has been changed since the plan was created.
+Options such as --emit and --ref-scope are read from the plan file.
+If they are specified and are different from the plan, tfmv refuses to apply the plan.
+
+$ tfmv apply tfmv.plan.json`,
+		Flags: concatFlags(commonFlags(flg), summaryFlags(flg), applyFlags(flg), emitFlags(flg), parallelismFlags(flg)),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			fs := afero.NewOsFs()
+			if _, err := r.setup(cmd, fs, flg); err != nil {
+				return err
+			}
+			if len(flg.Args) != 1 {
+				return errors.New("tfmv apply requires exactly one argument: a plan file path")
+			}
+			diffColor, err := r.diffColor(flg.DiffColor)
+			if err != nil {
+				return err
+			}
+			if err := validateEmit(flg); err != nil {
+				return err
+			}
+			input := &domain.Input{
+				DryRun:      flg.DryRun,
+				DiffColor:   diffColor,
+				Format:      flg.Format,
+				Output:      flg.Output,
+				Parallelism: flg.Parallelism,
+			}
+			// Options which decide changed files are read from the plan file.
+			// Only options specified explicitly are passed to check conflicts with the plan.
+			if cmd.IsSet("emit") {
+				input.Emit = flg.Emit
+			}
+			if cmd.IsSet("state-mv-file") {
+				input.StateMvFile = flg.StateMvFile
+			}
+			if cmd.IsSet("tfmigrate-file") {
+				input.TFMigrateFile = flg.TFMigrateFile
+			}
+			if cmd.IsSet("ref-scope") {
+				input.RefScope = flg.RefScope
+			}
+			return r.newController(fs).ApplyPlan(r.Logger.Logger, input, flg.Args[0]) //nolint:wrapcheck
+		},
+	}
+}
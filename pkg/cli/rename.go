@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/urfave/cli/v3"
+)
+
+func (r *Runner) renameCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "rename",
+		Usage:     "Rename blocks and generate moved blocks",
+		ArgsUsage: "[file ...]",
+		Description: `Rename Terraform resources, data sources, and modules, fix references, and generate moved blocks.
+One of --jsonnet (-j), --starlark, --replace (-r), --regexp, --command, or --changes must be specified unless rules are defined in the configuration file.
+By default, *.tf in the current directory are renamed. You can pass *.tf via arguments.
+If the argument is "-", tfmv reads the content of a file from stdin and writes the rewritten content to stdout (stream mode).
+
+$ tfmv rename -r "-/_"
+$ tfmv rename -r "-/_" main.tf foo.tf
+$ tfmv rename -r "-/_" --stdin-path foo/main.tf - < foo/main.tf`,
+		Flags: concatFlags(commonFlags(flg), renamerFlags(flg), summaryFlags(flg), applyFlags(flg), emitFlags(flg), parallelismFlags(flg), streamFlags(flg), []cli.Flag{
+			&cli.BoolFlag{
+				Name:        "check",
+				Usage:       `Check if blocks would be renamed without changing files. This is same as "tfmv check"`,
+				Destination: &flg.Check,
+			},
+		}),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return r.rename(cmd, flg)
+		},
+	}
+}
+
+func (r *Runner) checkCommand() *cli.Command {
+	flg := newFlag()
+	return &cli.Command{
+		Name:      "check",
+		Usage:     "Check if blocks would be renamed without changing files",
+		ArgsUsage: "[file ...]",
+		Description: `Check if blocks would be renamed without changing files.
+If any block would be renamed, tfmv exits with the code 3.
+This is useful to enforce naming rules in CI.
+
+$ tfmv check -r "-/_"`,
+		Flags: concatFlags(commonFlags(flg), renamerFlags(flg), summaryFlags(flg), parallelismFlags(flg)),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			flg.Check = true
+			return r.rename(cmd, flg)
+		},
+	}
+}
+
+// rename runs the rename command and the check command.
+func (r *Runner) rename(cmd *cli.Command, flg *Flag) error {
+	fs := afero.NewOsFs()
+	cfg, err := r.setup(cmd, fs, flg)
+	if err != nil {
+		return err
+	}
+	stream := len(flg.Args) == 1 && flg.Args[0] == "-"
+	if stream && flg.Changes == "-" {
+		return errors.New(`--changes can't be "-" in stream mode because stdin is used for the content`)
+	}
+	input, err := r.input(cmd, cfg, flg)
+	if err != nil {
+		return err
+	}
+	if stream {
+		return r.stream(fs, input, flg)
+	}
+	return r.newController(fs).Run(r.Logger.Logger, input) //nolint:wrapcheck
+}
+
+// stream runs the rename command in stream mode.
+// The content of a file is read from stdin and the rewritten content is written to stdout.
+func (r *Runner) stream(fs afero.Fs, input *domain.Input, flg *Flag) error {
+	moved, err := r.movedWriter(flg.MovedFD)
+	if err != nil {
+		return err
+	}
+	return r.newController(fs).Stream(r.Logger.Logger, input, flg.StdinPath, r.Stdin, moved) //nolint:wrapcheck
+}
+
+// movedWriter returns a writer of the file descriptor fd where moved blocks are written.
+// If fd is zero, movedWriter returns nil.
+func (r *Runner) movedWriter(fd int) (io.Writer, error) {
+	switch fd {
+	case 0:
+		return nil, nil //nolint:nilnil
+	case 1:
+		return r.Stdout, nil
+	case 2: //nolint:mnd
+		return r.Stderr, nil
+	}
+	if fd < 0 {
+		return nil, errors.New("--moved-fd must be a positive number")
+	}
+	return os.NewFile(uintptr(fd), "moved-fd"), nil
+}
@@ -1,44 +1,34 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"path/filepath"
+	"os"
 	"regexp"
-	"strings"
+	"slices"
 
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/afero"
-	flag "github.com/spf13/pflag"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
 	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfmv/pkg/config"
 	"github.com/suzuki-shunsuke/tfmv/pkg/controller"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/urfave/cli/v3"
 )
 
-const help = `tfmv - Rename Terraform resources, data sources, and modules and generate moved blocks.
+const description = `Rename Terraform resources, data sources, and modules and generate moved blocks.
 https://github.com/suzuki-shunsuke/tfmv
 
-Usage:
-	tfmv [<options>] [file ...]
-
-One of --jsonnet (-j), --replace (-r), or --regexp must be specified.
-
-Options:
-	--help, -h       Show help
-	--version, -v    Show tfmv version
-	--replace, -r    Replace strings in block names. The format is <old>/<new>. e.g. -/_
-	--jsonnet, -j    Jsonnet file path
-	--regexp         Replace strings in block names by regular expression. The format is <regular expression>/<new>. e.g. '\bfoo\b/bar'
-	--recursive, -R  If this is set, tfmv finds files recursively
-	--include        A regular expression to filter resources. Only resources that match the regular expression are renamed
-	--exclude        A regular expression to filter resources. Only resources that don't match the regular expression are renamed
-	--dry-run        Dry Run
-	--log-level      Log level
-	--log-color      Log color. "auto", "always", "never" are available
-	--moved, -m      A file name where moved blocks are written. If this is "same", the file is same with renamed resources`
+If no subcommand is specified, tfmv runs the rename command.
+e.g. "tfmv -r -/_" is same as "tfmv rename -r -/_".`
 
 type Runner struct {
+	// Args is a list of command line arguments excluding the program name.
+	Args        []string
 	Stdin       io.Reader
 	Stdout      io.Writer
 	Stderr      io.Writer
@@ -54,92 +44,222 @@ type LDFlags struct {
 }
 
 func (r *Runner) Run() error {
-	flg := &Flag{}
-	parseFlags(flg)
-	if flg.Version {
-		fmt.Fprintln(r.Stdout, r.LDFlags.Version)
-		return nil
+	return r.command().Run(context.Background(), append([]string{"tfmv"}, aliasRename(r.Args)...)) //nolint:wrapcheck
+}
+
+// version returns the version of tfmv.
+// If the version isn't set, version returns "(devel)".
+func (r *Runner) version() string {
+	if r.LDFlags.Version == "" {
+		// urfave/cli hides --version if the version is empty
+		return "(devel)"
 	}
-	if flg.Help {
-		fmt.Fprintln(r.Stdout, help)
-		return nil
+	return r.LDFlags.Version
+}
+
+// command returns the root command.
+func (r *Runner) command() *cli.Command {
+	return &cli.Command{
+		Name:                  "tfmv",
+		Usage:                 "Rename Terraform resources, data sources, and modules and generate moved blocks",
+		Description:           description,
+		Version:               r.version(),
+		Reader:                r.Stdin,
+		Writer:                r.Stdout,
+		ErrWriter:             r.Stderr,
+		EnableShellCompletion: true,
+		Commands: []*cli.Command{
+			r.renameCommand(),
+			r.checkCommand(),
+			r.planCommand(),
+			r.applyCommand(),
+			r.movedCommand(),
+			r.versionCommand(),
+		},
+	}
+}
+
+// rootArgs is a list of arguments handled by the root command.
+// If the first argument isn't one of them or subcommands, the rename command is run.
+var rootArgs = []string{"help", "h", "completion", "--help", "-h", "--version", "-v", "--generate-shell-completion"} //nolint:gochecknoglobals
+
+// subcommands is a list of subcommand names.
+var subcommands = []string{"rename", "check", "plan", "apply", "moved", "version"} //nolint:gochecknoglobals
+
+// aliasRename prepends "rename" to args if no subcommand is specified.
+// This keeps "tfmv -r -/_" working as an alias of "tfmv rename -r -/_".
+func aliasRename(args []string) []string {
+	if len(args) != 0 && (slices.Contains(rootArgs, args[0]) || slices.Contains(subcommands, args[0])) {
+		return args
+	}
+	return append([]string{"rename"}, args...)
+}
+
+func (r *Runner) versionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Show version",
+		Action: func(_ context.Context, _ *cli.Command) error {
+			fmt.Fprintln(r.Stdout, r.version())
+			return nil
+		},
+	}
+}
+
+// setup reads a configuration file and configures the logger.
+// If no configuration file is found, setup returns nil.
+func (r *Runner) setup(cmd *cli.Command, fs afero.Fs, flg *Flag) (*config.Config, error) {
+	flg.Args = cmd.Args().Slice()
+	cfg, err := loadConfig(fs, cmd, flg)
+	if err != nil {
+		return nil, err
 	}
 	if err := r.Logger.SetLevel(flg.LogLevel); err != nil {
-		return fmt.Errorf("set log level: %w", err)
+		return nil, fmt.Errorf("set log level: %w", err)
 	}
 	if err := r.Logger.SetColor(flg.LogColor); err != nil {
-		return fmt.Errorf("set log color: %w", err)
+		return nil, fmt.Errorf("set log color: %w", err)
 	}
-	if flg.Moved != "same" {
-		if !strings.HasSuffix(flg.Moved, ".tf") {
-			return errors.New("--moved name must be either 'same' or a file name with the suffix .tf")
-		}
-		if filepath.Base(flg.Moved) != flg.Moved {
-			return errors.New("--moved name must be either 'same' or a file name with the suffix .tf")
-		}
+	return cfg, nil
+}
+
+// newController creates a Controller.
+func (r *Runner) newController(fs afero.Fs) *controller.Controller {
+	ctrl := &controller.Controller{}
+	ctrl.Init(fs, r.Stdout, r.Stderr)
+	return ctrl
+}
+
+// input converts flags to domain.Input.
+func (r *Runner) input(cmd *cli.Command, cfg *config.Config, flg *Flag) (*domain.Input, error) {
+	diffColor, err := r.diffColor(flg.DiffColor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateEmit(flg); err != nil {
+		return nil, err
+	}
+
+	if err := domain.ValidateMovedFile(flg.Moved); err != nil {
+		return nil, err //nolint:wrapcheck
 	}
 
 	include, err := getRegexFilter(flg.Include)
 	if err != nil {
-		return fmt.Errorf("--include is an invalid regular expression: %w", err)
+		return nil, fmt.Errorf("--include is an invalid regular expression: %w", err)
 	}
 
 	exclude, err := getRegexFilter(flg.Exclude)
 	if err != nil {
-		return fmt.Errorf("--exclude is an invalid regular expression: %w", err)
+		return nil, fmt.Errorf("--exclude is an invalid regular expression: %w", err)
 	}
 
-	ctrl := &controller.Controller{}
-	ctrl.Init(afero.NewOsFs(), r.Stdout, r.Stderr)
-	return ctrl.Run(r.Logger.Logger, &domain.Input{ //nolint:wrapcheck
-		Jsonnet:   flg.Jsonnet,
-		MovedFile: flg.Moved,
-		Recursive: flg.Recursive,
-		DryRun:    flg.DryRun,
-		Args:      flg.Args,
-		Replace:   flg.Replace,
-		Include:   include,
-		Exclude:   exclude,
-		Regexp:    flg.Regexp,
-	})
+	changes, err := r.readChanges(flg.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := getRules(cfg, cmd, flg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Input{
+		Jsonnet:        flg.Jsonnet,
+		Starlark:       flg.Starlark,
+		MovedFile:      flg.Moved,
+		Recursive:      flg.Recursive,
+		Ignore:         flg.Ignore,
+		ChangedSince:   flg.ChangedSince,
+		RootModules:    flg.RootModules,
+		Parallelism:    flg.Parallelism,
+		RefScope:       flg.RefScope,
+		Tolerant:       flg.Tolerant,
+		DryRun:         flg.DryRun,
+		Check:          flg.Check,
+		DiffColor:      diffColor,
+		Args:           flg.Args,
+		Replace:        flg.Replace,
+		Format:         flg.Format,
+		Output:         flg.Output,
+		Include:        include,
+		Exclude:        exclude,
+		Regexp:         flg.Regexp,
+		Command:        flg.Command,
+		CommandTimeout: flg.CommandTimeout,
+		CommandBatch:   flg.CommandBatch,
+		Changes:        changes,
+		Rules:          rules,
+		Emit:           flg.Emit,
+		StateMvFile:    flg.StateMvFile,
+		TFMigrateFile:  flg.TFMigrateFile,
+	}, nil
 }
 
-func getRegexFilter(s string) (*regexp.Regexp, error) {
-	if s == "" {
-		return nil, nil //nolint:nilnil
+// validateEmit validates --emit, --state-mv-file, and --tfmigrate-file options.
+func validateEmit(flg *Flag) error {
+	if err := domain.ValidateEmit(flg.Emit); err != nil {
+		return err //nolint:wrapcheck
 	}
-	return regexp.Compile(s) //nolint:wrapcheck
+	if err := domain.ValidateFileName("--state-mv-file", flg.StateMvFile); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if err := domain.ValidateFileName("--tfmigrate-file", flg.TFMigrateFile); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if err := domain.ValidateRefScope(flg.RefScope); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
 }
 
-type Flag struct {
-	Jsonnet   string
-	Moved     string
-	LogLevel  string
-	LogColor  string
-	Replace   string
-	Regexp    string
-	Include   string
-	Exclude   string
-	Args      []string
-	Help      bool
-	Version   bool
-	Recursive bool
-	DryRun    bool
+// readChanges reads a JSON file of changes.
+// If path is "-", changes are read from stdin.
+func (r *Runner) readChanges(path string) ([]*domain.Change, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if path == "-" {
+		changes, err := controller.DecodeChanges(r.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read changes from stdin: %w", err)
+		}
+		return changes, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open a file of changes: %w", err)
+	}
+	defer f.Close()
+	changes, err := controller.DecodeChanges(f)
+	if err != nil {
+		return nil, fmt.Errorf("read changes: %w", slogerr.With(err, "file", path))
+	}
+	return changes, nil
+}
+
+// diffColor returns true if a diff should be colorized.
+func (r *Runner) diffColor(s string) (bool, error) {
+	switch s {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		f, ok := r.Stderr.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		return isatty.IsTerminal(f.Fd()), nil
+	default:
+		return false, errors.New(`--diff-color must be one of "auto", "always", "never"`)
+	}
 }
 
-func parseFlags(f *Flag) {
-	flag.StringVarP(&f.Jsonnet, "jsonnet", "j", "", "Jsonnet file path")
-	flag.StringVarP(&f.Moved, "moved", "m", "moved.tf", "The destination file name")
-	flag.StringVarP(&f.Replace, "replace", "r", "", "Replace strings in block names. The format is <old>/<new>. e.g. -/_")
-	flag.StringVar(&f.Regexp, "regexp", "", "Replace strings in block names by regular expression. The format is <regular expression>/<new>. e.g. '\bfoo\b/bar'")
-	flag.StringVar(&f.Include, "include", "", "A regular expression to filter resources")
-	flag.StringVar(&f.Exclude, "exclude", "", "A regular expression to filter resources")
-	flag.StringVar(&f.LogLevel, "log-level", "info", "The log level")
-	flag.StringVar(&f.LogColor, "log-color", "auto", "The log color")
-	flag.BoolVarP(&f.Help, "help", "h", false, "Show help")
-	flag.BoolVarP(&f.Version, "version", "v", false, "Show version")
-	flag.BoolVarP(&f.Recursive, "recursive", "R", false, "If this is set, tfmv finds files recursively")
-	flag.BoolVar(&f.DryRun, "dry-run", false, "Dry Run")
-	flag.Parse()
-	f.Args = flag.Args()
+func getRegexFilter(s string) (*regexp.Regexp, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil
+	}
+	return regexp.Compile(s) //nolint:wrapcheck
 }
@@ -0,0 +1,116 @@
+package cli_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfmv/pkg/cli"
+)
+
+func TestAliasRename(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args []string
+		exp  []string
+	}{
+		{
+			name: "no argument",
+			args: []string{},
+			exp:  []string{"rename"},
+		},
+		{
+			name: "flags of rename",
+			args: []string{"-r", "-/_", "main.tf"},
+			exp:  []string{"rename", "-r", "-/_", "main.tf"},
+		},
+		{
+			name: "file",
+			args: []string{"main.tf"},
+			exp:  []string{"rename", "main.tf"},
+		},
+		{
+			name: "stdin",
+			args: []string{"-"},
+			exp:  []string{"rename", "-"},
+		},
+		{
+			name: "subcommand",
+			args: []string{"check", "-r", "-/_"},
+			exp:  []string{"check", "-r", "-/_"},
+		},
+		{
+			name: "nested subcommand",
+			args: []string{"moved", "list"},
+			exp:  []string{"moved", "list"},
+		},
+		{
+			name: "help",
+			args: []string{"help", "rename"},
+			exp:  []string{"help", "rename"},
+		},
+		{
+			name: "--version",
+			args: []string{"--version"},
+			exp:  []string{"--version"},
+		},
+		{
+			name: "completion",
+			args: []string{"completion", "bash"},
+			exp:  []string{"completion", "bash"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := cli.AliasRename(tt.args); !slices.Equal(got, tt.exp) {
+				t.Fatalf("wanted %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestRunner_Run_version(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		version string
+		exp     string
+	}{
+		{
+			name:    "version",
+			version: "v1.0.0",
+			exp:     "v1.0.0",
+		},
+		{
+			name: "devel",
+			exp:  "(devel)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for _, args := range [][]string{{"version"}, {"--version"}} {
+				stdout := &bytes.Buffer{}
+				runner := &cli.Runner{
+					Args:    args,
+					Stdin:   strings.NewReader(""),
+					Stdout:  stdout,
+					Stderr:  io.Discard,
+					LDFlags: &cli.LDFlags{Version: tt.version},
+					Logger:  slogutil.New(&slogutil.InputNew{Name: "tfmv"}),
+				}
+				if err := runner.Run(); err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasSuffix(stdout.String(), tt.exp+"\n") {
+					t.Fatalf("%v: wanted %q, got %q", args, tt.exp, stdout.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package config reads a configuration file of tfmv.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// fileNames is a list of configuration file names.
+// They are searched in order in each directory.
+var fileNames = []string{".tfmv.yaml", ".tfmv.yml"} //nolint:gochecknoglobals
+
+// Config is a configuration of tfmv.
+// Each field is a default value of a command line option of the same name.
+// Empty values are ignored.
+type Config struct {
+	Jsonnet        string   `yaml:"jsonnet"`
+	Starlark       string   `yaml:"starlark"`
+	Replace        string   `yaml:"replace"`
+	Regexp         string   `yaml:"regexp"`
+	Command        string   `yaml:"command"`
+	CommandTimeout string   `yaml:"command_timeout"`
+	CommandBatch   bool     `yaml:"command_batch"`
+	Changes        string   `yaml:"changes"`
+	Include        string   `yaml:"include"`
+	Exclude        string   `yaml:"exclude"`
+	Moved          string   `yaml:"moved"`
+	Recursive      bool     `yaml:"recursive"`
+	Tolerant       bool     `yaml:"tolerant"`
+	Ignore         []string `yaml:"ignore"`
+	ChangedSince   string   `yaml:"changed_since"`
+	RootModules    []string `yaml:"root_modules"`
+	DryRun         bool     `yaml:"dry_run"`
+	Check          bool     `yaml:"check"`
+	DiffColor      string   `yaml:"diff_color"`
+	LogLevel       string   `yaml:"log_level"`
+	LogColor       string   `yaml:"log_color"`
+	Format         string   `yaml:"format"`
+	Output         string   `yaml:"output"`
+	Emit           []string `yaml:"emit"`
+	StateMvFile    string   `yaml:"state_mv_file"`
+	TFMigrateFile  string   `yaml:"tfmigrate_file"`
+	Parallelism    int      `yaml:"parallelism"`
+	RefScope       string   `yaml:"ref_scope"`
+	// Rules is an ordered list of rename rules.
+	// Rules can't be used with top-level renamers such as replace.
+	Rules []*Rule `yaml:"rules"`
+	// dir is a directory where the configuration file exists.
+	dir string
+}
+
+// Find finds a configuration file from the directory wd upward.
+// It returns a file path relative to wd.
+// If no configuration file is found, it returns an empty string.
+func Find(fs afero.Fs, wd string) (string, error) {
+	dir := wd
+	rel := "."
+	for {
+		for _, name := range fileNames {
+			if _, err := fs.Stat(filepath.Join(dir, name)); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return "", fmt.Errorf("check if a configuration file exists: %w", slogerr.With(err, "file", filepath.Join(dir, name)))
+			}
+			return filepath.Join(rel, name), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+		rel = filepath.Join(rel, "..")
+	}
+}
+
+// Read reads a configuration file.
+// Unknown fields are rejected to detect typos.
+func Read(fs afero.Fs, path string) (*Config, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read a configuration file: %w", err)
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalWithOptions(b, cfg, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("parse a configuration file as YAML: %w", slogerr.With(err, "file", path))
+	}
+	cfg.dir = filepath.Dir(path)
+	if err := cfg.validateRules(); err != nil {
+		return nil, slogerr.With(err, "file", path) //nolint:wrapcheck
+	}
+	return cfg, nil
+}
+
+// Path converts a file path in the configuration file to a path relative to the current directory.
+// Relative paths in the configuration file are relative to the directory where the configuration file exists.
+func (c *Config) Path(p string) string {
+	if p == "" || p == "-" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
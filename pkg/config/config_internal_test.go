@@ -0,0 +1,100 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/config"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files []string
+		wd    string
+		exp   string
+	}{
+		{
+			name:  "current directory",
+			files: []string{"/repo/foo/.tfmv.yaml", "/repo/.tfmv.yaml"},
+			wd:    "/repo/foo",
+			exp:   ".tfmv.yaml",
+		},
+		{
+			name:  "parent directory",
+			files: []string{"/repo/.tfmv.yml"},
+			wd:    "/repo/foo/bar",
+			exp:   filepath.Join("..", "..", ".tfmv.yml"),
+		},
+		{
+			name: "not found",
+			wd:   "/repo/foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			for _, file := range tt.files {
+				if err := afero.WriteFile(fs, file, []byte("replace: -/_\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := config.Find(fs, tt.wd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, p)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+		jsonnet string
+		isErr   bool
+	}{
+		{
+			name: "normal",
+			content: `jsonnet: tfmv.jsonnet
+emit: [moved, state-mv]
+`,
+			jsonnet: filepath.Join("..", "tfmv.jsonnet"),
+		},
+		{
+			name:    "unknown field",
+			content: "replac: -/_\n",
+			isErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			path := filepath.Join("..", ".tfmv.yaml")
+			if err := afero.WriteFile(fs, path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.Read(fs, path)
+			if err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			if p := cfg.Path(cfg.Jsonnet); p != tt.jsonnet {
+				t.Fatalf("wanted %q, got %q", tt.jsonnet, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// Rule is a rename rule in a configuration file.
+type Rule struct {
+	Name           string   `yaml:"name"`
+	Replace        string   `yaml:"replace"`
+	Regexp         string   `yaml:"regexp"`
+	Jsonnet        string   `yaml:"jsonnet"`
+	Starlark       string   `yaml:"starlark"`
+	Command        string   `yaml:"command"`
+	CommandTimeout string   `yaml:"command_timeout"`
+	CommandBatch   bool     `yaml:"command_batch"`
+	Include        string   `yaml:"include"`
+	Exclude        string   `yaml:"exclude"`
+	Dirs           []string `yaml:"dirs"`
+	BlockTypes     []string `yaml:"block_types"`
+	Moved          string   `yaml:"moved"`
+}
+
+// hasRenamer returns true if a top-level renamer is configured.
+func (c *Config) hasRenamer() bool {
+	for _, s := range []string{c.Replace, c.Regexp, c.Jsonnet, c.Starlark, c.Command, c.Changes} {
+		if s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRules validates that rules and top-level renamers aren't used together.
+func (c *Config) validateRules() error {
+	if len(c.Rules) != 0 && c.hasRenamer() {
+		return errors.New("rules can't be used with replace, regexp, jsonnet, starlark, command, and changes")
+	}
+	return nil
+}
+
+// DomainRules converts rules to domain.Rule.
+// Rules without names are named by their indices such as "rules[0]".
+// commandTimeout is a default timeout of commands.
+func (c *Config) DomainRules(commandTimeout time.Duration) ([]*domain.Rule, error) {
+	if len(c.Rules) == 0 {
+		return nil, nil
+	}
+	baseDir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("get an absolute path of the configuration file directory: %w", err)
+	}
+	rules := make([]*domain.Rule, len(c.Rules))
+	for i, r := range c.Rules {
+		name := r.Name
+		if name == "" {
+			name = "rules[" + strconv.Itoa(i) + "]"
+		}
+		rule, err := c.domainRule(r, name, baseDir, commandTimeout)
+		if err != nil {
+			return nil, slogerr.With(err, "rule", name) //nolint:wrapcheck
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+func (c *Config) domainRule(r *Rule, name, baseDir string, commandTimeout time.Duration) (*domain.Rule, error) {
+	rule := &domain.Rule{
+		Name:           name,
+		Replace:        r.Replace,
+		Regexp:         r.Regexp,
+		Jsonnet:        c.Path(r.Jsonnet),
+		Starlark:       c.Path(r.Starlark),
+		Command:        r.Command,
+		CommandTimeout: commandTimeout,
+		CommandBatch:   r.CommandBatch,
+		Dirs:           r.Dirs,
+		BaseDir:        baseDir,
+		BlockTypes:     r.BlockTypes,
+		MovedFile:      r.Moved,
+	}
+	if r.CommandTimeout != "" {
+		d, err := time.ParseDuration(r.CommandTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("parse command_timeout: %w", err)
+		}
+		rule.CommandTimeout = d
+	}
+	if r.Include != "" {
+		include, err := regexp.Compile(r.Include)
+		if err != nil {
+			return nil, fmt.Errorf("include is an invalid regular expression: %w", err)
+		}
+		rule.Include = include
+	}
+	if r.Exclude != "" {
+		exclude, err := regexp.Compile(r.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("exclude is an invalid regular expression: %w", err)
+		}
+		rule.Exclude = exclude
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, fmt.Errorf("validate a rule: %w", err)
+	}
+	return rule, nil
+}
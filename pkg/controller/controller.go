@@ -1,12 +1,27 @@
 package controller
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 
 	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
 )
 
+var (
+	// ErrChangesFound is returned in check mode if any block would be renamed.
+	ErrChangesFound = errors.New("some blocks would be renamed")
+	// ErrInvalidConfiguration is returned if the verification finds issues in the rewritten configuration.
+	ErrInvalidConfiguration = errors.New("the rewritten configuration is invalid")
+	// ErrDirsSkipped is returned if directories are skipped in tolerant mode.
+	ErrDirsSkipped = errors.New("some directories are skipped because files can't be parsed")
+)
+
 type Controller struct {
 	fs     afero.Fs
 	stdout io.Writer
@@ -24,29 +39,124 @@ func (c *Controller) Init(fs afero.Fs, stdout, stderr io.Writer) {
 // It is used to output a summary of changes.
 type Summary struct {
 	// Changes is a list of changes.
+	// Changes are sorted by directory, file, line, and address so that summaries can be compared between runs.
 	Changes []*Change `json:"changes"`
+	// SkippedDirs is a list of directories skipped in tolerant mode.
+	SkippedDirs []*domain.SkippedDir `json:"skipped_dirs,omitempty" yaml:"skipped_dirs,omitempty"`
+	// SkippedTFMigrateFiles is a list of tfmigrate migration files which aren't written because they already exist.
+	SkippedTFMigrateFiles []string `json:"skipped_tfmigrate_files,omitempty" yaml:"skipped_tfmigrate_files,omitempty"`
+}
+
+// NewSummary creates a Summary from a list of directories.
+func NewSummary(dirs map[string]*domain.Dir) *Summary {
+	summary := &Summary{}
+	summary.FromDirs(dirs)
+	return summary
 }
 
 // FromDirs updates the Summary from a list of directories.
 func (s *Summary) FromDirs(dirs map[string]*domain.Dir) {
 	s.Changes = []*Change{}
+	s.SkippedTFMigrateFiles = nil
 	for _, dir := range dirs {
+		if dir.SkippedTFMigrateFile != "" {
+			s.SkippedTFMigrateFiles = append(s.SkippedTFMigrateFiles, dir.SkippedTFMigrateFile)
+		}
 		for _, block := range dir.Blocks {
-			s.Changes = append(s.Changes, &Change{
-				Dir:        dir.Path,
-				Address:    block.TFAddress,
-				NewAddress: block.NewTFAddress,
-			})
+			change := &Change{
+				Dir:               dir.Path,
+				File:              block.File,
+				Line:              block.Line,
+				BlockType:         block.BlockType,
+				Address:           block.TFAddress,
+				NewAddress:        block.NewTFAddress,
+				MovedBlockWritten: block.MovedBlockWritten,
+				RefFiles:          sortRefFiles(block.RefFiles),
+				UnfixedRefFiles:   sortRefFiles(block.UnfixedRefFiles),
+			}
+			if !block.IsData() {
+				change.MovedFile = block.MovedFile
+			}
+			if block.Rule != nil {
+				change.Rule = block.Rule.Name
+			}
+			if change.RefFiles == nil {
+				change.RefFiles = []*domain.RefFile{}
+			}
+			s.Changes = append(s.Changes, change)
 		}
 	}
+	slices.SortFunc(s.Changes, func(a, b *Change) int {
+		return cmp.Or(
+			cmp.Compare(a.Dir, b.Dir),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Address, b.Address),
+		)
+	})
+	slices.Sort(s.SkippedTFMigrateFiles)
+}
+
+// sortRefFiles returns a copy of files sorted by file path.
+func sortRefFiles(files []*domain.RefFile) []*domain.RefFile {
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b *domain.RefFile) int {
+		return cmp.Compare(a.File, b.File)
+	})
+	return files
 }
 
 // Change represents a change of a Terraform block.
 type Change struct {
 	// Dir is a Terraform module directory path.
-	Dir string `json:"dir"`
+	Dir string `json:"dir" yaml:"dir"`
+	// File is a file path where the block is defined.
+	File string `json:"file" yaml:"file"`
+	// Line is a line number where the block is defined.
+	Line int `json:"line" yaml:"line"`
+	// BlockType is one of "resource", "data", or "module".
+	BlockType string `json:"block_type" yaml:"block_type"`
 	// Address is a current Terraform address.
-	Address string `json:"address"`
+	Address string `json:"address" yaml:"address"`
 	// NewAddress is a new Terraform address.
-	NewAddress string `json:"new_address"`
+	NewAddress string `json:"new_address" yaml:"new_address"`
+	// MovedFile is a file path where a moved block is written.
+	// Data sources don't have moved blocks, so this is empty.
+	MovedFile string `json:"moved_file,omitempty" yaml:"moved_file,omitempty"`
+	// Rule is a name of the rule which renamed the block.
+	// This is empty if rules aren't used.
+	Rule string `json:"rule,omitempty" yaml:"rule,omitempty"`
+	// MovedBlockWritten is true if a moved block is written.
+	// In dry-run mode, this is true if a moved block would be written.
+	MovedBlockWritten bool `json:"moved_block_written" yaml:"moved_block_written"`
+	// RefFiles is a list of files where references to the block are rewritten.
+	RefFiles []*domain.RefFile `json:"ref_files" yaml:"ref_files"`
+	// UnfixedRefFiles is a list of files outside the reference scope where references to the block remain.
+	UnfixedRefFiles []*domain.RefFile `json:"unfixed_ref_files,omitempty" yaml:"unfixed_ref_files,omitempty"`
+}
+
+// RefCount returns the total number of rewritten references.
+func (c *Change) RefCount() int {
+	count := 0
+	for _, f := range c.RefFiles {
+		count += f.Count
+	}
+	return count
+}
+
+// DecodeChanges decodes a summary JSON and returns a list of changes.
+// It is the inverse of Summary, so the output of tfmv can be passed to tfmv again.
+func DecodeChanges(r io.Reader) ([]*domain.Change, error) {
+	summary := &struct {
+		Changes []*domain.Change `json:"changes"`
+	}{}
+	if err := json.NewDecoder(r).Decode(summary); err != nil {
+		return nil, fmt.Errorf("decode changes as JSON: %w", err)
+	}
+	for i, change := range summary.Changes {
+		if change.Dir == "" || change.Address == "" || change.NewAddress == "" {
+			return nil, slogerr.With(errors.New("dir, address, and new_address are required"), "index", i) //nolint:wrapcheck
+		}
+	}
+	return summary.Changes, nil
 }
@@ -0,0 +1,87 @@
+package controller_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/controller"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+func TestSummary_FromDirs(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	newBlock := func(file string, line int, blockType, name, movedFile string, refFiles ...*domain.RefFile) *domain.Block {
+		b := &domain.Block{
+			File:         file,
+			Line:         line,
+			BlockType:    blockType,
+			ResourceType: "null_resource",
+			Name:         name,
+			MovedFile:    movedFile,
+			RefFiles:     refFiles,
+		}
+		if blockType == "module" {
+			b.ResourceType = ""
+		}
+		if err := b.Init(); err != nil {
+			t.Fatal(err)
+		}
+		b.SetNewName(name + "_new")
+		b.MovedBlockWritten = !b.IsData()
+		return b
+	}
+	dirs := map[string]*domain.Dir{
+		"foo": {
+			Path: "foo",
+			Blocks: []*domain.Block{
+				newBlock("foo/main.tf", 10, "resource", "b", "foo/moved.tf",
+					&domain.RefFile{File: "foo/outputs.tf", Count: 2},
+					&domain.RefFile{File: "foo/main.tf", Count: 1}),
+				newBlock("foo/main.tf", 1, "resource", "a", "foo/moved.tf"),
+				newBlock("foo/data.tf", 5, "data", "c", "foo/moved.tf"),
+			},
+		},
+		"bar": {
+			Path: "bar",
+			Blocks: []*domain.Block{
+				newBlock("bar/main.tf", 3, "module", "d", "bar/moved.tf"),
+			},
+			SkippedTFMigrateFile: "bar/tfmv_tfmigrate.hcl",
+		},
+		"baz": {
+			Path:                 "baz",
+			SkippedTFMigrateFile: "baz/tfmv_tfmigrate.hcl",
+		},
+	}
+	// The result must not depend on the iteration order of the map
+	for i := range 10 {
+		summary := controller.NewSummary(dirs)
+		got := make([]string, len(summary.Changes))
+		for j, c := range summary.Changes {
+			refs := ""
+			for _, f := range c.RefFiles {
+				refs += fmt.Sprintf(" %s=%d", f.File, f.Count)
+			}
+			got[j] = fmt.Sprintf("%s %s:%d %s %s -> %s moved_file=%s written=%t refs=%d%s",
+				c.Dir, c.File, c.Line, c.BlockType, c.Address, c.NewAddress, c.MovedFile, c.MovedBlockWritten, c.RefCount(), refs)
+		}
+		exp := []string{
+			"bar bar/main.tf:3 module module.d -> module.d_new moved_file=bar/moved.tf written=true refs=0",
+			"foo foo/data.tf:5 data data.null_resource.c -> data.null_resource.c_new moved_file= written=false refs=0",
+			"foo foo/main.tf:1 resource null_resource.a -> null_resource.a_new moved_file=foo/moved.tf written=true refs=0",
+			"foo foo/main.tf:10 resource null_resource.b -> null_resource.b_new moved_file=foo/moved.tf written=true refs=3 foo/main.tf=1 foo/outputs.tf=2",
+		}
+		if len(got) != len(exp) {
+			t.Fatalf("wanted %d changes, got %d", len(exp), len(got))
+		}
+		for j := range exp {
+			if got[j] != exp[j] {
+				t.Fatalf("#%d: change %d: wanted %q, got %q", i, j, exp[j], got[j])
+			}
+		}
+		if !slices.Equal(summary.SkippedTFMigrateFiles, []string{"bar/tfmv_tfmigrate.hcl", "baz/tfmv_tfmigrate.hcl"}) {
+			t.Fatalf("#%d: skipped tfmigrate files: got %v", i, summary.SkippedTFMigrateFiles)
+		}
+	}
+}
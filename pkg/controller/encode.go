@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// SummaryEncoder encodes a summary.
+type SummaryEncoder interface {
+	Encode(w io.Writer, summary *Summary) error
+}
+
+// summaryEncoders is a map of output formats and SummaryEncoder.
+func summaryEncoders() map[string]SummaryEncoder {
+	return map[string]SummaryEncoder{
+		"json":     &JSONEncoder{},
+		"jsonl":    &JSONLinesEncoder{},
+		"yaml":     &YAMLEncoder{},
+		"table":    &TableEncoder{},
+		"markdown": &MarkdownEncoder{},
+	}
+}
+
+// SummaryFormats returns a sorted list of available output formats.
+func SummaryFormats() []string {
+	return slices.Sorted(maps.Keys(summaryEncoders()))
+}
+
+// NewSummaryEncoder returns a SummaryEncoder for a given output format.
+// If format is empty, JSON is used.
+func NewSummaryEncoder(format string) (SummaryEncoder, error) {
+	if format == "" {
+		format = "json"
+	}
+	encoder, ok := summaryEncoders()[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s. Available formats: %s", format, strings.Join(SummaryFormats(), ", "))
+	}
+	return encoder, nil
+}
+
+// JSONEncoder encodes a summary as indented JSON.
+type JSONEncoder struct{}
+
+func (e *JSONEncoder) Encode(w io.Writer, summary *Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return fmt.Errorf("encode a summary as JSON: %w", err)
+	}
+	return nil
+}
+
+// JSONLinesEncoder encodes each change as a line of JSON.
+type JSONLinesEncoder struct{}
+
+func (e *JSONLinesEncoder) Encode(w io.Writer, summary *Summary) error {
+	encoder := json.NewEncoder(w)
+	for _, change := range summary.Changes {
+		if err := encoder.Encode(change); err != nil {
+			return fmt.Errorf("encode a change as JSON: %w", err)
+		}
+	}
+	return nil
+}
+
+// YAMLEncoder encodes a summary as YAML.
+type YAMLEncoder struct{}
+
+func (e *YAMLEncoder) Encode(w io.Writer, summary *Summary) error {
+	if err := yaml.NewEncoder(w).Encode(summary); err != nil {
+		return fmt.Errorf("encode a summary as YAML: %w", err)
+	}
+	return nil
+}
+
+// TableEncoder encodes a summary as a plain text table.
+type TableEncoder struct{}
+
+func (e *TableEncoder) Encode(w io.Writer, summary *Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+	fmt.Fprintln(tw, "FILE\tADDRESS\tNEW ADDRESS\tMOVED FILE\tREFERENCES")
+	for _, change := range summary.Changes {
+		fmt.Fprintf(tw, "%s:%d\t%s\t%s\t%s\t%d\n", change.File, change.Line, change.Address, change.NewAddress, change.MovedFile, change.RefCount())
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("output a table: %w", err)
+	}
+	return nil
+}
+
+// MarkdownEncoder encodes a summary as a Markdown table.
+type MarkdownEncoder struct{}
+
+func (e *MarkdownEncoder) Encode(w io.Writer, summary *Summary) error {
+	if len(summary.Changes) == 0 {
+		if _, err := fmt.Fprintln(w, "No block is renamed."); err != nil {
+			return fmt.Errorf("output Markdown: %w", err)
+		}
+		return nil
+	}
+	lines := make([]string, 0, len(summary.Changes)+2) //nolint:mnd
+	lines = append(lines, "| File | Address | New Address | Moved File | References |", "| --- | --- | --- | --- | --: |")
+	for _, change := range summary.Changes {
+		lines = append(lines, fmt.Sprintf("| %s:%d | `%s` | `%s` | %s | %d |", escapeMarkdown(change.File), change.Line, change.Address, change.NewAddress, escapeMarkdown(change.MovedFile), change.RefCount()))
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(lines, "\n")); err != nil {
+		return fmt.Errorf("output Markdown: %w", err)
+	}
+	return nil
+}
+
+// escapeMarkdown escapes characters which break a Markdown table.
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
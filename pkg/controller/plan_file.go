@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/apply"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/plan"
+	"github.com/suzuki-shunsuke/tfmv/pkg/planfile"
+)
+
+// Plan plans changes and writes them to a plan file without changing Terraform files.
+// The plan file can be applied by ApplyPlan.
+// In tolerant mode, skipped directories aren't included in the plan and Plan returns ErrDirsSkipped.
+func (c *Controller) Plan(logger *slog.Logger, input *domain.Input, out string) error {
+	encoder, err := NewSummaryEncoder(input.Format)
+	if err != nil {
+		return err
+	}
+
+	planner := plan.NewPlanner(c.fs)
+	dirs, skipped, err := planner.Plan(logger, input)
+	if err != nil {
+		return fmt.Errorf("plan changes: %w", err)
+	}
+
+	scopeFiles, err := apply.New(c.fs, c.stderr).ScopeFiles(input, dirs, domain.SkippedDirPaths(skipped))
+	if err != nil {
+		return fmt.Errorf("find files in the reference scope: %w", err)
+	}
+	p, err := planfile.New(c.fs, input, dirs, skipped, scopeFiles)
+	if err != nil {
+		return fmt.Errorf("create a plan: %w", err)
+	}
+	if err := p.Write(c.fs, out); err != nil {
+		return fmt.Errorf("write a plan file: %w", slogerr.With(err, "plan_file", out))
+	}
+	logger.Info("wrote a plan file", "plan_file", out)
+
+	summary := NewSummary(dirs)
+	summary.SkippedDirs = skipped
+	if err := c.summarize(encoder, input.Output, summary); err != nil {
+		slogerr.WithError(logger, err).Warn("output changed summary")
+	}
+	return skippedErr(skipped)
+}
+
+// ApplyPlan applies a plan file created by Plan.
+// ApplyPlan refuses to apply the plan if any file in the reference scope or any Jsonnet and Starlark file
+// has been changed since the plan was created.
+// Settings such as input.Emit and input.RefScope are read from the plan.
+// If input has different settings, ApplyPlan refuses to apply the plan.
+func (c *Controller) ApplyPlan(logger *slog.Logger, input *domain.Input, planFile string) error {
+	encoder, err := NewSummaryEncoder(input.Format)
+	if err != nil {
+		return err
+	}
+
+	p, err := planfile.Read(c.fs, planFile)
+	if err != nil {
+		return fmt.Errorf("read a plan file: %w", slogerr.With(err, "plan_file", planFile))
+	}
+	if err := p.Settings.SetTo(input); err != nil {
+		return fmt.Errorf("check options: %w", slogerr.With(err, "plan_file", planFile))
+	}
+	dirs, err := p.ToDirs()
+	if err != nil {
+		return fmt.Errorf("load a plan: %w", slogerr.With(err, "plan_file", planFile))
+	}
+	scopeFiles, err := apply.New(c.fs, c.stderr).ScopeFiles(input, dirs, p.SkippedDirs)
+	if err != nil {
+		return fmt.Errorf("find files in the reference scope: %w", err)
+	}
+	if err := p.Verify(c.fs, scopeFiles); err != nil {
+		return fmt.Errorf("the plan is stale: %w", slogerr.With(err, "plan_file", planFile))
+	}
+	input.Args = p.Args
+	result, err := c.apply(logger, input, dirs, p.SkippedDirs)
+	return c.output(logger, encoder, input, result, err)
+}
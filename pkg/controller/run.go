@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 
@@ -9,34 +8,146 @@ import (
 	"github.com/suzuki-shunsuke/tfmv/pkg/apply"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
 	"github.com/suzuki-shunsuke/tfmv/pkg/plan"
+	"github.com/suzuki-shunsuke/tfmv/pkg/verify"
 )
 
+// Result is a result of Exec.
+type Result struct {
+	// Dirs is a plan. The key is a directory path.
+	Dirs map[string]*domain.Dir
+	// Summary is a summary of changes.
+	Summary *Summary
+	// Issues is a list of issues found by the verification after applying changes.
+	Issues []*verify.Issue
+}
+
+// Run plans and applies changes and outputs a summary.
 func (c *Controller) Run(logger *slog.Logger, input *domain.Input) error {
+	encoder, err := NewSummaryEncoder(input.Format)
+	if err != nil {
+		return err
+	}
+	result, err := c.Exec(logger, input)
+	return c.output(logger, encoder, input, result, err)
+}
+
+// Exec plans and applies changes and returns the result.
+// Exec doesn't output a summary, so it can be used as a library.
+// In check mode, Exec doesn't apply changes.
+// If the verification finds issues, Exec returns both the result and ErrInvalidConfiguration.
+// In tolerant mode, if any directory is skipped, Exec returns both the result and ErrDirsSkipped.
+func (c *Controller) Exec(logger *slog.Logger, input *domain.Input) (*Result, error) {
 	planner := plan.NewPlanner(c.fs)
-	dirs, err := planner.Plan(logger, input)
+	dirs, skipped, err := planner.Plan(logger, input)
 	if err != nil {
-		return fmt.Errorf("plan changes: %w", err)
+		return nil, fmt.Errorf("plan changes: %w", err)
 	}
 
-	if err := c.summarize(dirs); err != nil {
-		slogerr.WithError(logger, err).Warn("output changed summary")
+	if input.Check {
+		result := &Result{
+			Dirs:    dirs,
+			Summary: NewSummary(dirs),
+		}
+		result.Summary.SkippedDirs = skipped
+		return result, skippedErr(skipped)
 	}
 
-	applier := apply.New(c.fs, c.stderr)
-	if err := applier.Apply(logger, input, dirs); err != nil {
-		return fmt.Errorf("apply changes: %w", err)
+	result, err := c.apply(logger, input, dirs, domain.SkippedDirPaths(skipped))
+	if result != nil {
+		result.Summary.SkippedDirs = skipped
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, skippedErr(skipped)
+}
+
+// skippedErr returns ErrDirsSkipped if any directory is skipped.
+func skippedErr(skipped []*domain.SkippedDir) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	return slogerr.With(ErrDirsSkipped, "num_of_skipped_dirs", len(skipped)) //nolint:wrapcheck
+}
+
+// output outputs a summary and issues of the result of Exec.
+// In check mode, output returns ErrChangesFound if any block would be renamed.
+func (c *Controller) output(logger *slog.Logger, encoder SummaryEncoder, input *domain.Input, result *Result, err error) error {
+	if result == nil {
+		return err
+	}
+	if e := c.summarize(encoder, input.Output, result.Summary); e != nil {
+		slogerr.WithError(logger, e).Warn("output changed summary")
+	}
+	for _, issue := range result.Issues {
+		fmt.Fprintln(c.stderr, issue)
+	}
+	if err != nil {
+		return err
+	}
+	if input.Check {
+		return c.check(logger, result.Summary)
 	}
 	return nil
 }
 
-// summarize outputs a summary of changes as JSON to stdout.
-func (c *Controller) summarize(dirs map[string]*domain.Dir) error {
-	summary := &Summary{}
-	summary.FromDirs(dirs)
-	encoder := json.NewEncoder(c.stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(summary); err != nil {
-		return fmt.Errorf("encode a summary as JSON: %w", err)
+// apply applies changes and verifies the rewritten configuration.
+// Files in skippedDirs aren't changed.
+func (c *Controller) apply(logger *slog.Logger, input *domain.Input, dirs map[string]*domain.Dir, skippedDirs []string) (*Result, error) {
+	applier := apply.New(c.fs, c.stderr)
+	if err := applier.Apply(logger, input, dirs, skippedDirs); err != nil {
+		return nil, fmt.Errorf("apply changes: %w", err)
+	}
+
+	result := &Result{
+		Dirs:    dirs,
+		Summary: NewSummary(dirs),
+	}
+	if input.DryRun || len(dirs) == 0 {
+		return result, nil
+	}
+
+	verifier := verify.New(c.fs)
+	issues, err := verifier.Verify(logger, dirs)
+	if err != nil {
+		return nil, fmt.Errorf("verify changes: %w", err)
+	}
+	result.Issues = issues
+	if len(issues) != 0 {
+		return result, slogerr.With(ErrInvalidConfiguration, "num_of_issues", len(issues)) //nolint:wrapcheck
+	}
+	return result, nil
+}
+
+// check outputs blocks which would be renamed.
+// If any block would be renamed, check returns ErrChangesFound.
+func (c *Controller) check(logger *slog.Logger, summary *Summary) error {
+	for _, change := range summary.Changes {
+		logger.Warn("a block would be renamed", "file", change.File, "address", change.Address, "new_address", change.NewAddress)
+	}
+	if len(summary.Changes) == 0 {
+		return nil
+	}
+	return slogerr.With(ErrChangesFound, "num_of_changes", len(summary.Changes)) //nolint:wrapcheck
+}
+
+// summarize outputs a summary of changes.
+// If output is empty, the summary is written to stdout.
+// Otherwise, the summary is written to the file output.
+func (c *Controller) summarize(encoder SummaryEncoder, output string, summary *Summary) error {
+	if output == "" {
+		return encoder.Encode(c.stdout, summary) //nolint:wrapcheck
+	}
+	f, err := c.fs.Create(output)
+	if err != nil {
+		return fmt.Errorf("create a summary file: %w", slogerr.With(err, "file", output))
+	}
+	if err := encoder.Encode(f, summary); err != nil {
+		f.Close()
+		return err //nolint:wrapcheck
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close a summary file: %w", slogerr.With(err, "file", output))
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package controller_test
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"log/slog"
 	"path/filepath"
@@ -20,6 +21,10 @@ func TestController_Run(t *testing.T) { //nolint:funlen
 		stdout io.Writer
 		stderr io.Writer
 		input  *domain.Input
+		// expFiles is expected contents of files after running.
+		expFiles map[string]string
+		// expErr is an expected sentinel error.
+		expErr error
 		isErr  bool
 	}{
 		{
@@ -80,6 +85,113 @@ func TestController_Run(t *testing.T) { //nolint:funlen
 				DryRun:  true,
 			},
 		},
+		{
+			name: "markdown output file",
+			files: map[string]string{
+				"testdata/main.tf": `resource "null_resource" "example-1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Args:      []string{"testdata/main.tf"},
+				Replace:   "-/_",
+				Format:    "markdown",
+				Output:    "summary.md",
+				MovedFile: "moved.tf",
+				DryRun:    true,
+			},
+			expFiles: map[string]string{
+				"summary.md": "| File | Address | New Address | Moved File | References |\n" +
+					"| --- | --- | --- | --- | --: |\n" +
+					"| testdata/main.tf:1 | `null_resource.example-1` | `null_resource.example_1` | testdata/moved.tf | 0 |\n",
+			},
+		},
+		{
+			name: "invalid format",
+			files: map[string]string{
+				"testdata/main.tf": `resource "null_resource" "example-1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Args:    []string{"testdata/main.tf"},
+				Replace: "-/_",
+				Format:  "xml",
+				DryRun:  true,
+			},
+			isErr: true,
+		},
+		{
+			name: "check",
+			files: map[string]string{
+				"testdata/main.tf": `resource "null_resource" "example-1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Args:    []string{"testdata/main.tf"},
+				Replace: "-/_",
+				Check:   true,
+			},
+			expErr: controller.ErrChangesFound,
+			isErr:  true,
+		},
+		{
+			name: "check no change",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "example_1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Args:    []string{"main.tf"},
+				Replace: "-/_",
+				Check:   true,
+			},
+		},
+		{
+			name: "changes",
+			files: map[string]string{
+				"testdata/main.tf": `resource "null_resource" "example-1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Changes: []*domain.Change{
+					{
+						Dir:        "testdata",
+						Address:    "null_resource.example-1",
+						NewAddress: "null_resource.example_1",
+					},
+				},
+				DryRun: true,
+			},
+		},
+		{
+			name: "changes address not found",
+			files: map[string]string{
+				"testdata/main.tf": `resource "null_resource" "example-1" {}
+`,
+			},
+			stdout: &bytes.Buffer{},
+			stderr: &bytes.Buffer{},
+			input: &domain.Input{
+				Changes: []*domain.Change{
+					{
+						Dir:        "testdata",
+						Address:    "null_resource.example-2",
+						NewAddress: "null_resource.example_2",
+					},
+				},
+				DryRun: true,
+			},
+			isErr: true,
+		},
 		{
 			name: "no renamer",
 			files: map[string]string{
@@ -117,6 +229,9 @@ func TestController_Run(t *testing.T) { //nolint:funlen
 			ctrl := &controller.Controller{}
 			ctrl.Init(fs, tt.stdout, tt.stderr)
 			if err := ctrl.Run(logger, tt.input); err != nil {
+				if tt.expErr != nil && !errors.Is(err, tt.expErr) {
+					t.Fatalf("wanted %v, got %v", tt.expErr, err)
+				}
 				if tt.isErr {
 					return
 				}
@@ -125,6 +240,15 @@ func TestController_Run(t *testing.T) { //nolint:funlen
 			if tt.isErr {
 				t.Fatal("error is expected")
 			}
+			for path, exp := range tt.expFiles {
+				b, err := afero.ReadFile(fs, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != exp {
+					t.Fatalf("%s: wanted %q, got %q", path, exp, string(b))
+				}
+			}
 		})
 	}
 }
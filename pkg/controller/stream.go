@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/verify"
+)
+
+// StreamResult is a result of ExecStream.
+type StreamResult struct {
+	*Result
+	// Content is the rewritten content of the file.
+	Content []byte
+	// MovedBlocks is generated moved blocks.
+	// If moved blocks are appended to Content, this is empty.
+	MovedBlocks []byte
+}
+
+// Stream reads the content of a file from stdin, renames blocks in it, and writes the rewritten content to stdout.
+// If moved is nil, moved blocks are appended to the content.
+// Otherwise, moved blocks are written to moved.
+// A summary is written only if input.Output is set because stdout is used for the content.
+func (c *Controller) Stream(logger *slog.Logger, input *domain.Input, path string, stdin io.Reader, moved io.Writer) error {
+	encoder, err := NewSummaryEncoder(input.Format)
+	if err != nil {
+		return err
+	}
+	src, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+	if moved == nil {
+		input.MovedFile = "same"
+	}
+	result, err := c.ExecStream(logger, input, path, src)
+	if result == nil {
+		return err
+	}
+	for _, issue := range result.Issues {
+		fmt.Fprintln(c.stderr, issue)
+	}
+	if err != nil {
+		return err
+	}
+	if input.Output != "" {
+		if err := c.summarize(encoder, input.Output, result.Summary); err != nil {
+			slogerr.WithError(logger, err).Warn("output changed summary")
+		}
+	}
+	if _, err := c.stdout.Write(result.Content); err != nil {
+		return fmt.Errorf("write the content to stdout: %w", err)
+	}
+	if moved != nil && len(result.MovedBlocks) != 0 {
+		if _, err := moved.Write(result.MovedBlocks); err != nil {
+			return fmt.Errorf("write moved blocks: %w", err)
+		}
+	}
+	return nil
+}
+
+// defaultStdinPath is a file path of the content if the path isn't given.
+const defaultStdinPath = "stdin.tf"
+
+// ExecStream renames blocks in src, the content of the file path, and returns the rewritten content.
+// The file needn't exist and the file system isn't changed.
+// If path is empty, the content is handled as stdin.tf in the current directory.
+// Then path is required if *.tf exist in the current directory, otherwise they would be verified with the content as duplicates.
+// Other files in the same directory are read to verify the result, but they aren't changed.
+// References in them aren't fixed but are reported as unfixed references.
+// If input.MovedFile is "same", moved blocks are appended to the content.
+// Otherwise, moved blocks are returned separately.
+func (c *Controller) ExecStream(logger *slog.Logger, input *domain.Input, path string, src []byte) (*StreamResult, error) {
+	if input.DryRun || input.Check {
+		return nil, errors.New("stream mode can't be used with dry-run mode and check mode")
+	}
+	for _, e := range input.Emit {
+		if e != domain.EmitMoved {
+			return nil, slogerr.With(errors.New("stream mode supports only moved blocks"), "emit", e) //nolint:wrapcheck
+		}
+	}
+	if path == "" {
+		files, err := afero.Glob(c.fs, "*.tf")
+		if err != nil {
+			return nil, fmt.Errorf("find files: %w", err)
+		}
+		if len(files) != 0 {
+			return nil, slogerr.With(errors.New("the file path of the content is required because *.tf exist in the current directory"), "file", files[0]) //nolint:wrapcheck
+		}
+		path = defaultStdinPath
+	}
+	in := *input
+	in.Args = []string{path}
+	// Only the content is changed, so references in other files are reported instead of being fixed
+	in.RefScope = domain.RefScopeFile
+	// All moved blocks are written to one file
+	in.Rules = make([]*domain.Rule, len(input.Rules))
+	for i, rule := range input.Rules {
+		r := *rule
+		r.MovedFile = ""
+		in.Rules[i] = &r
+	}
+
+	// Files are read from the file system but written in memory
+	overlay := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(c.fs), afero.NewMemMapFs())
+	if err := overlay.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("create a directory in memory: %w", slogerr.With(err, "file", path))
+	}
+	if err := afero.WriteFile(overlay, path, src, 0o644); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("write the content in memory: %w", slogerr.With(err, "file", path))
+	}
+	ctrl := &Controller{}
+	ctrl.Init(overlay, io.Discard, c.stderr)
+	result, err := ctrl.Exec(logger, &in)
+	if result == nil {
+		return nil, err
+	}
+	// files changed in stream mode
+	files := []string{path}
+	movedFile := filepath.Join(filepath.Dir(path), in.MovedFile)
+	if in.MovedFile != "same" {
+		files = append(files, movedFile)
+	}
+	if errors.Is(err, ErrInvalidConfiguration) {
+		result.Issues = streamIssues(result.Issues, files)
+		err = nil
+		if len(result.Issues) != 0 {
+			err = slogerr.With(ErrInvalidConfiguration, "num_of_issues", len(result.Issues))
+		}
+	}
+	sr := &StreamResult{Result: result}
+	content, e := afero.ReadFile(overlay, path)
+	if e != nil {
+		return nil, fmt.Errorf("read the rewritten content: %w", slogerr.With(e, "file", path))
+	}
+	sr.Content = content
+	if in.MovedFile != "same" {
+		movedBlocks, e := c.newContent(overlay, movedFile)
+		if e != nil {
+			return nil, e
+		}
+		sr.MovedBlocks = movedBlocks
+	}
+	return sr, err
+}
+
+// streamIssues returns issues in the content and the moved file.
+// Issues in other files are excluded because they aren't changed in stream mode.
+// References remaining in them are reported as unfixed references instead.
+func streamIssues(issues []*verify.Issue, files []string) []*verify.Issue {
+	arr := make([]*verify.Issue, 0, len(issues))
+	for _, issue := range issues {
+		for _, file := range files {
+			if filepath.Clean(issue.File) == filepath.Clean(file) {
+				arr = append(arr, issue)
+				break
+			}
+		}
+	}
+	return arr
+}
+
+// newContent returns content appended to a file in overlay.
+func (c *Controller) newContent(overlay afero.Fs, path string) ([]byte, error) {
+	content, err := afero.ReadFile(overlay, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	orig, err := afero.ReadFile(c.fs, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return content, nil
+		}
+		return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	return bytes.TrimPrefix(bytes.TrimPrefix(content, orig), []byte("\n")), nil
+}
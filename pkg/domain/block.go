@@ -15,6 +15,8 @@ const (
 type Block struct {
 	// File is a file path
 	File string `json:"file"`
+	// Line is a line number where the block is defined.
+	Line int `json:"-"`
 	// BlockType is one of "resource", "data", or "module"
 	BlockType string `json:"block_type"`
 	// ResourceType is a resource type such as "aws_instance"
@@ -36,6 +38,23 @@ type Block struct {
 	NewTFAddress string `json:"-"`
 	// NewHCLAddress is a new HCL address.
 	NewHCLAddress string `json:"-"`
+	// Rule is a rule which renamed the block.
+	// This is nil if rules aren't used.
+	Rule *Rule `json:"-"`
+	// MovedBlockWritten is true if a moved block is written.
+	MovedBlockWritten bool `json:"-"`
+	// RefFiles is a list of files where references to the block are rewritten.
+	RefFiles []*RefFile `json:"-"`
+	// UnfixedRefFiles is a list of files outside the reference scope where references to the block remain.
+	UnfixedRefFiles []*RefFile `json:"-"`
+}
+
+// RefFile represents a file where references to a block are rewritten.
+type RefFile struct {
+	// File is a file path.
+	File string `json:"file" yaml:"file"`
+	// Count is the number of rewritten references.
+	Count int `json:"count" yaml:"count"`
 }
 
 // isResource returns true if blockType is "resource".
@@ -101,6 +120,14 @@ func (b *Block) Regstr() string {
 	return ""
 }
 
+// NewLabels returns labels of the block after renaming.
+func (b *Block) NewLabels() []string {
+	if b.BlockType == wordModule {
+		return []string{b.NewName}
+	}
+	return []string{b.ResourceType, b.NewName}
+}
+
 // SetNewName sets updates a new name, a new HCL address, and a new Terraform address.
 func (b *Block) SetNewName(newName string) {
 	b.NewName = newName
@@ -120,7 +147,18 @@ func (b *Block) Init() error {
 	return nil
 }
 
-// Fix replaces resource references with a new Terraform address.
-func (b *Block) Fix(body string) string {
-	return b.Regexp.ReplaceAllString(body, b.NewTFAddress)
+// AddUnfixedRefFile records that references to the block remain in a file outside the reference scope.
+func (b *Block) AddUnfixedRefFile(file string, count int) {
+	b.UnfixedRefFiles = append(b.UnfixedRefFiles, &RefFile{
+		File:  file,
+		Count: count,
+	})
+}
+
+// AddRefFile records that references to the block are rewritten in a file.
+func (b *Block) AddRefFile(file string, count int) {
+	b.RefFiles = append(b.RefFiles, &RefFile{
+		File:  file,
+		Count: count,
+	})
 }
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// isRef returns false if a match src[start:end] is a part of a longer address.
+// e.g. aws_instance.foo in aws_instance.foo-bar or data.aws_instance.foo.
+func isRef(src string, start, end int) bool {
+	if start > 0 && src[start-1] == '.' {
+		return false
+	}
+	if end < len(src) && isIdentifierChar(src[end]) {
+		return false
+	}
+	return true
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// FindRefs returns indexes of references to the block in src.
+func (b *Block) FindRefs(src []byte) [][]int {
+	s := string(src)
+	refs := [][]int{}
+	for _, idx := range b.Regexp.FindAllStringIndex(s, -1) {
+		if isRef(s, idx[0], idx[1]) {
+			refs = append(refs, idx)
+		}
+	}
+	return refs
+}
+
+// RefMatcher finds references to old addresses of blocks in one scan.
+type RefMatcher struct {
+	re *regexp.Regexp
+	// blocks maps an old Terraform address to a block.
+	blocks map[string]*Block
+}
+
+// NewRefMatcher creates a RefMatcher.
+// If blocks are empty, NewRefMatcher returns nil.
+func NewRefMatcher(blocks []*Block) (*RefMatcher, error) {
+	if len(blocks) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	m := &RefMatcher{
+		blocks: make(map[string]*Block, len(blocks)),
+	}
+	addrs := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if _, ok := m.blocks[b.TFAddress]; ok {
+			continue
+		}
+		m.blocks[b.TFAddress] = b
+		addrs = append(addrs, b.TFAddress)
+	}
+	// Longer addresses are preferred so that "aws_instance.foo-1" isn't matched as "aws_instance.foo".
+	slices.SortFunc(addrs, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a, b))
+	})
+	quoted := make([]string, len(addrs))
+	for i, addr := range addrs {
+		quoted[i] = regexp.QuoteMeta(addr)
+	}
+	re, err := regexp.Compile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	if err != nil {
+		return nil, fmt.Errorf("compile a regular expression to capture references: %w", err)
+	}
+	m.re = re
+	return m, nil
+}
+
+// Replace replaces references to old addresses with new addresses in src.
+// It also returns the number of replaced references to each block.
+// src is scanned only once, so a replaced reference is never replaced again.
+// This means that blocks can swap their names.
+func (m *RefMatcher) Replace(src string) (string, map[*Block]int) {
+	counts := map[*Block]int{}
+	if m == nil {
+		return src, counts
+	}
+	var sb strings.Builder
+	last := 0
+	for _, idx := range m.re.FindAllStringIndex(src, -1) {
+		if !isRef(src, idx[0], idx[1]) {
+			continue
+		}
+		b := m.blocks[src[idx[0]:idx[1]]]
+		counts[b]++
+		sb.WriteString(src[last:idx[0]])
+		sb.WriteString(b.NewTFAddress)
+		last = idx[1]
+	}
+	if last == 0 {
+		return src, counts
+	}
+	sb.WriteString(src[last:])
+	return sb.String(), counts
+}
+
+// Count returns the number of references to each block in src.
+func (m *RefMatcher) Count(src string) map[*Block]int {
+	counts := map[*Block]int{}
+	if m == nil {
+		return counts
+	}
+	for _, idx := range m.re.FindAllStringIndex(src, -1) {
+		if isRef(src, idx[0], idx[1]) {
+			counts[m.blocks[src[idx[0]:idx[1]]]]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,88 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+func TestRefMatcher_Replace(t *testing.T) {
+	t.Parallel()
+	newBlock := func(blockType, resourceType, name, newName string) *domain.Block {
+		b := &domain.Block{
+			BlockType:    blockType,
+			ResourceType: resourceType,
+			Name:         name,
+		}
+		if err := b.Init(); err != nil {
+			t.Fatal(err)
+		}
+		b.SetNewName(newName)
+		return b
+	}
+	tests := []struct {
+		name   string
+		blocks []*domain.Block
+		src    string
+		exp    string
+		counts []int
+	}{
+		{
+			name: "replace references",
+			blocks: []*domain.Block{
+				newBlock("resource", "null_resource", "foo-1", "foo_1"),
+				newBlock("module", "", "bar-1", "bar_1"),
+			},
+			src:    `x = [null_resource.foo-1.id, module.bar-1.id, "${null_resource.foo-1.id}"]`,
+			exp:    `x = [null_resource.foo_1.id, module.bar_1.id, "${null_resource.foo_1.id}"]`,
+			counts: []int{2, 1},
+		},
+		{
+			name: "swap names",
+			blocks: []*domain.Block{
+				newBlock("resource", "null_resource", "foo", "bar"),
+				newBlock("resource", "null_resource", "bar", "foo"),
+			},
+			src:    `x = [null_resource.foo.id, null_resource.bar.id]`,
+			exp:    `x = [null_resource.bar.id, null_resource.foo.id]`,
+			counts: []int{1, 1},
+		},
+		{
+			name: "longer names and addresses",
+			blocks: []*domain.Block{
+				newBlock("resource", "null_resource", "foo", "foo_0"),
+			},
+			src:    `x = [null_resource.foo-1.id, data.null_resource.foo.id, null_resource.foo_2.id]`,
+			exp:    `x = [null_resource.foo-1.id, data.null_resource.foo.id, null_resource.foo_2.id]`,
+			counts: []int{0},
+		},
+		{
+			name: "prefer a longer address",
+			blocks: []*domain.Block{
+				newBlock("resource", "null_resource", "foo", "foo_0"),
+				newBlock("resource", "null_resource", "foo-1", "foo_1"),
+			},
+			src:    `x = [null_resource.foo-1.id, null_resource.foo.id]`,
+			exp:    `x = [null_resource.foo_1.id, null_resource.foo_0.id]`,
+			counts: []int{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := domain.NewRefMatcher(tt.blocks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, counts := m.Replace(tt.src)
+			if got != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, got)
+			}
+			for i, b := range tt.blocks {
+				if counts[b] != tt.counts[i] {
+					t.Fatalf("%s: wanted %d references, got %d", b.TFAddress, tt.counts[i], counts[b])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// Rule is a rename rule with its own renamer and scope.
+// Rules are evaluated in order and the first rule whose scope matches a block renames the block.
+type Rule struct {
+	// Name is a rule name. It is recorded in the summary.
+	Name string
+	// Replace, Regexp, Jsonnet, Starlark, and Command are renamers. One of them must be specified.
+	Replace  string
+	Regexp   string
+	Jsonnet  string
+	Starlark string
+	Command  string
+	// CommandTimeout is a timeout of each execution of Command.
+	// If this is zero, DefaultCommandTimeout is used.
+	// If this is negative, the command never times out.
+	CommandTimeout time.Duration
+	// CommandBatch is true if all blocks matching the rule are passed to Command at once.
+	CommandBatch bool
+	// Include is a regular expression of Terraform addresses the rule applies to.
+	Include *regexp.Regexp
+	// Exclude is a regular expression of Terraform addresses the rule doesn't apply to.
+	Exclude *regexp.Regexp
+	// Dirs is a list of glob patterns of directories the rule applies to.
+	// A pattern also matches subdirectories of matching directories.
+	// If this is empty, the rule applies to all directories.
+	Dirs []string
+	// BaseDir is a directory which Dirs are relative to.
+	// If this is empty, Dirs are relative to the current directory.
+	BaseDir string
+	// BlockTypes is a list of block types the rule applies to.
+	// If this is empty, the rule applies to all block types.
+	BlockTypes []string
+	// MovedFile is a file name where moved blocks are written.
+	// If this is empty, the global option is used.
+	MovedFile string
+}
+
+// Validate validates the rule.
+func (r *Rule) Validate() error {
+	renamers := 0
+	for _, s := range []string{r.Replace, r.Regexp, r.Jsonnet, r.Starlark, r.Command} {
+		if s != "" {
+			renamers++
+		}
+	}
+	if renamers != 1 {
+		return slogerr.With(errors.New("a rule must have exactly one of replace, regexp, jsonnet, starlark, and command"), "rule", r.Name) //nolint:wrapcheck
+	}
+	for _, t := range r.BlockTypes {
+		if _, ok := Types()[t]; !ok {
+			return slogerr.With(errors.New("invalid block type"), "rule", r.Name, "block_type", t) //nolint:wrapcheck
+		}
+	}
+	for _, d := range r.Dirs {
+		if _, err := filepath.Match(d, ""); err != nil {
+			return slogerr.With(errors.New("invalid directory glob"), "rule", r.Name, "dir", d) //nolint:wrapcheck
+		}
+	}
+	if r.MovedFile != "" {
+		if err := ValidateMovedFile(r.MovedFile); err != nil {
+			return slogerr.With(err, "rule", r.Name) //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// Match returns true if the block is in the scope of the rule.
+func (r *Rule) Match(block *Block) bool {
+	if len(r.BlockTypes) != 0 && !slices.Contains(r.BlockTypes, block.BlockType) {
+		return false
+	}
+	if r.Exclude != nil && r.Exclude.MatchString(block.TFAddress) {
+		return false
+	}
+	if r.Include != nil && !r.Include.MatchString(block.TFAddress) {
+		return false
+	}
+	if len(r.Dirs) == 0 {
+		return true
+	}
+	dir := filepath.Dir(block.File)
+	if r.BaseDir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return false
+		}
+		rel, err := filepath.Rel(r.BaseDir, abs)
+		if err != nil {
+			return false
+		}
+		dir = rel
+	}
+	return r.matchDir(dir)
+}
+
+// matchDir returns true if dir or any parent directory of dir matches one of Dirs.
+func (r *Rule) matchDir(dir string) bool {
+	for {
+		for _, pattern := range r.Dirs {
+			if m, _ := filepath.Match(filepath.Clean(pattern), dir); m {
+				return true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// SkippedDir is a directory skipped in tolerant mode because some files can't be parsed.
+// No block in the directory is renamed, so references are never half-updated.
+type SkippedDir struct {
+	// Dir is a directory path.
+	Dir string `json:"dir" yaml:"dir"`
+	// Diagnostics is a list of parse errors of files in the directory.
+	Diagnostics []*Diagnostic `json:"diagnostics" yaml:"diagnostics"`
+}
+
+// SkippedDirPaths returns paths of skipped directories.
+func SkippedDirPaths(skipped []*SkippedDir) []string {
+	paths := make([]string, len(skipped))
+	for i, s := range skipped {
+		paths[i] = s.Dir
+	}
+	return paths
+}
+
+// Diagnostic is a parse error of a file.
+type Diagnostic struct {
+	// File is a file path.
+	File string `json:"file" yaml:"file"`
+	// Line is a line number starting from 1.
+	// This is zero if the position is unknown.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Column is a column number starting from 1.
+	// This is zero if the position is unknown.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+	// Summary is a short description of the error.
+	Summary string `json:"summary" yaml:"summary"`
+	// Detail is a detailed description of the error.
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// NewDiagnostics converts errors of hcl.Diagnostics to a list of Diagnostic.
+// Warnings are ignored.
+func NewDiagnostics(file string, diags hcl.Diagnostics) []*Diagnostic {
+	arr := make([]*Diagnostic, 0, len(diags))
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		d := &Diagnostic{
+			File:    file,
+			Summary: diag.Summary,
+			Detail:  diag.Detail,
+		}
+		if diag.Subject != nil {
+			d.Line = diag.Subject.Start.Line
+			d.Column = diag.Subject.Start.Column
+		}
+		arr = append(arr, d)
+	}
+	return arr
+}
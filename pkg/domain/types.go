@@ -1,26 +1,170 @@
 package domain
 
-import "regexp"
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// EmitMoved is a value of --emit option to generate moved blocks.
+	EmitMoved = "moved"
+	// EmitStateMv is a value of --emit option to generate shell scripts of `terraform state mv` commands.
+	EmitStateMv = "state-mv"
+	// EmitTFMigrate is a value of --emit option to generate tfmigrate migration files.
+	EmitTFMigrate = "tfmigrate"
+)
+
+// EmitTypes returns a list of available values of --emit option.
+func EmitTypes() []string {
+	return []string{EmitMoved, EmitStateMv, EmitTFMigrate}
+}
+
+// DefaultCommandTimeout is a timeout of each execution of the external command if the timeout isn't set.
+const DefaultCommandTimeout = 30 * time.Second
+
+const (
+	// RefScopeFile is a value of --ref-scope option to fix references only in processed files.
+	RefScopeFile = "file"
+	// RefScopeDir is a value of --ref-scope option to fix references in all *.tf in the directory.
+	RefScopeDir = "dir"
+	// RefScopeRecursive is a value of --ref-scope option to fix references in all *.tf in the directory and subdirectories belonging to the same module.
+	RefScopeRecursive = "recursive"
+)
+
+// RefScopes returns a list of available values of --ref-scope option.
+func RefScopes() []string {
+	return []string{RefScopeFile, RefScopeDir, RefScopeRecursive}
+}
+
+// ValidateRefScope validates a value of --ref-scope option.
+// An empty value means RefScopeDir.
+func ValidateRefScope(scope string) error {
+	if scope == "" || slices.Contains(RefScopes(), scope) {
+		return nil
+	}
+	return fmt.Errorf("--ref-scope must be one of %s: %s", strings.Join(RefScopes(), ", "), scope)
+}
 
 type Input struct {
 	// Jsonnet is a jsonnet option.
 	Jsonnet string
+	// Starlark is a starlark option.
+	Starlark string
 	// MovedFile is -moved option.
 	MovedFile string
 	// Replace is a replace option.
 	Replace string
 	// Regexp is a regexp option.
 	Regexp string
+	// Command is an external command to rename blocks.
+	Command string
+	// CommandTimeout is a timeout of each execution of the external command.
+	// If this is zero, DefaultCommandTimeout is used.
+	// If this is negative, the command never times out.
+	CommandTimeout time.Duration
+	// CommandBatch is true if all blocks are passed to the external command at once.
+	CommandBatch bool
 	// Include is an include option.
 	Include *regexp.Regexp
 	// Exclude is an exclude option.
 	Exclude *regexp.Regexp
+	// Rules is an ordered list of rename rules given by the configuration file.
+	Rules []*Rule
+	// Changes is a list of changes given by --changes option.
+	Changes []*Change
+	// Format is a summary output format.
+	Format string
+	// Output is a file path where a summary is written.
+	// If this is empty, a summary is written to stdout.
+	Output string
+	// Emit is a list of outputs to migrate Terraform states.
+	// If this is empty, only moved blocks are generated.
+	Emit []string
+	// StateMvFile is a file name of shell scripts of `terraform state mv` commands.
+	StateMvFile string
+	// TFMigrateFile is a file name of tfmigrate migration files.
+	TFMigrateFile string
 	// Args is a list of arguments.
 	Args []string
 	// Recursive is a recursive option.
 	Recursive bool
+	// RootModules is a list of root module directories.
+	// If this is set, the root modules and local modules called from them transitively are processed.
+	RootModules []string
+	// ChangedSince is a git ref.
+	// If this is set, only directories including *.tf changed since the ref are processed among files found as usual.
+	ChangedSince string
+	// Ignore is a list of gitignore style patterns of files and directories ignored in recursive discovery.
+	// Patterns are relative to the current directory.
+	Ignore []string
 	// DryRun is a dry-run option.
 	DryRun bool
+	// Check is a check option.
+	// If this is true, tfmv doesn't change files and fails if any block would be renamed.
+	Check bool
+	// DiffColor is true if a diff is colorized in dry-run mode.
+	DiffColor bool
+	// Tolerant skips directories including files which can't be parsed instead of failing.
+	Tolerant bool
+	// RefScope is a scope where references to renamed blocks are fixed.
+	// If this is empty, RefScopeDir is used.
+	RefScope string
+	// Parallelism is the maximum number of files, blocks, or directories processed concurrently.
+	// If this is zero or negative, the number of CPUs is used.
+	Parallelism int
+}
+
+// ValidateMovedFile validates a file name where moved blocks are written.
+// The name must be either "same" or a file name with the suffix .tf.
+func ValidateMovedFile(name string) error {
+	if name == "same" {
+		return nil
+	}
+	if !strings.HasSuffix(name, ".tf") || filepath.Base(name) != name {
+		return errors.New("--moved name must be either 'same' or a file name with the suffix .tf")
+	}
+	return nil
+}
+
+// ValidateEmit validates values of --emit option.
+func ValidateEmit(emit []string) error {
+	for _, e := range emit {
+		if !slices.Contains(EmitTypes(), e) {
+			return fmt.Errorf("--emit must be one of %s: %s", strings.Join(EmitTypes(), ", "), e)
+		}
+	}
+	return nil
+}
+
+// ValidateFileName validates that name is a file name rather than a file path.
+// option is an option name used in the error message.
+func ValidateFileName(option, name string) error {
+	if name == "" || filepath.Base(name) != name {
+		return fmt.Errorf("%s must be a file name: %s", option, name)
+	}
+	return nil
+}
+
+// Workers returns the maximum number of files, blocks, or directories processed concurrently.
+func (i *Input) Workers() int {
+	if i.Parallelism <= 0 {
+		return runtime.NumCPU()
+	}
+	return i.Parallelism
+}
+
+// Emits returns true if the output s is enabled.
+func (i *Input) Emits(s string) bool {
+	if len(i.Emit) == 0 {
+		return s == EmitMoved
+	}
+	return slices.Contains(i.Emit, s)
 }
 
 // Dir represents a Terraform Module directory.
@@ -31,4 +175,16 @@ type Dir struct {
 	Files []string
 	// Blocks is a list of renamed Terraform blocks.
 	Blocks []*Block
+	// SkippedTFMigrateFile is a path of a tfmigrate migration file which isn't written because it already exists.
+	SkippedTFMigrateFile string
+}
+
+// Change represents a rename of a block given by a user.
+type Change struct {
+	// Dir is a Terraform module directory path.
+	Dir string `json:"dir"`
+	// Address is a current Terraform address.
+	Address string `json:"address"`
+	// NewAddress is a new Terraform address.
+	NewAddress string `json:"new_address"`
 }
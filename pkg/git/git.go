@@ -0,0 +1,60 @@
+// Package git gets information from a local git repository.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// ChangedFiles returns files changed since the git ref in the directory dir.
+// Changes in the working tree, including uncommitted changes and untracked files, are included.
+// Untracked files ignored by git are excluded.
+// Paths are relative to dir, and files outside dir are excluded.
+// Deleted files are also returned.
+func ChangedFiles(dir, ref string) ([]string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, slogerr.With(errors.New("the git ref is invalid"), "ref", ref) //nolint:wrapcheck
+	}
+	diff, err := run(dir, "diff", "--name-only", "--relative", "-z", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("list changed files: %w", slogerr.With(err, "ref", ref))
+	}
+	untracked, err := run(dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("list untracked files: %w", err)
+	}
+	files := slices.Concat(splitNull(diff), splitNull(untracked))
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// splitNull splits NUL separated output of git.
+func splitNull(b []byte) []string {
+	files := []string{}
+	for _, file := range strings.Split(string(b), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// run runs a git command in the directory dir and returns stdout.
+func run(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run a git command: %w", slogerr.With(err, "args", strings.Join(args, " "), "stderr", stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/git"
+)
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil { //nolint:gosec
+			t.Fatal(err)
+		}
+	}
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if b, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, b)
+		}
+	}
+	writeFile(".gitignore", "ignored.tf\n")
+	writeFile("foo/main.tf", "")
+	writeFile("bar/main.tf", "")
+	writeFile("baz/main.tf", "")
+	gitRun("init", "-q")
+	gitRun("add", "-A")
+	gitRun("commit", "-q", "-m", "init")
+	gitRun("tag", "base")
+	writeFile("foo/main.tf", "# changed\n")
+	gitRun("commit", "-q", "-am", "change foo")
+	writeFile("bar/main.tf", "# uncommitted\n")
+	writeFile("qux/main.tf", "")
+	writeFile("qux/ignored.tf", "")
+	if err := os.Remove(filepath.Join(dir, "baz", "main.tf")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := git.ChangedFiles(dir, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"bar/main.tf", "baz/main.tf", "foo/main.tf", "qux/main.tf"}
+	if !slices.Equal(files, exp) {
+		t.Fatalf("wanted %v, got %v", exp, files)
+	}
+
+	// paths are relative to the directory
+	files, err = git.ChangedFiles(filepath.Join(dir, "foo"), "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"main.tf"}; !slices.Equal(files, exp) {
+		t.Fatalf("wanted %v, got %v", exp, files)
+	}
+
+	if _, err := git.ChangedFiles(dir, "--output=foo"); err == nil {
+		t.Fatal("an invalid ref should be rejected")
+	}
+}
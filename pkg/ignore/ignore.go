@@ -0,0 +1,166 @@
+// Package ignore matches file paths with gitignore style patterns.
+package ignore
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// Matcher is a list of patterns in an ignore file.
+// Patterns are relative to the directory Base.
+type Matcher struct {
+	// Base is a directory where the ignore file exists.
+	Base     string
+	patterns []*pattern
+}
+
+// pattern is a compiled gitignore pattern.
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Compile compiles lines of an ignore file.
+// base is a directory where the ignore file exists.
+func Compile(base string, lines []string) (*Matcher, error) {
+	m := &Matcher{Base: base}
+	for i, line := range lines {
+		p, err := compilePattern(line)
+		if err != nil {
+			return nil, slogerr.With(err, "line", i+1, "pattern", line) //nolint:wrapcheck
+		}
+		if p == nil {
+			continue
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// Parse parses the content of an ignore file.
+func Parse(base string, src []byte) (*Matcher, error) {
+	return Compile(base, strings.Split(string(src), "\n"))
+}
+
+// Match returns whether the path p is ignored.
+// matched is false if no pattern matches p, and then ignored must be ignored.
+// p is a path relative to the current directory.
+func (m *Matcher) Match(p string, isDir bool) (ignored, matched bool) {
+	rel, err := filepath.Rel(m.Base, p)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	// The last matching pattern wins
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		pt := m.patterns[i]
+		if pt.dirOnly && !isDir {
+			continue
+		}
+		if pt.re.MatchString(rel) {
+			return !pt.negate, true
+		}
+	}
+	return false, false
+}
+
+// Ignored returns true if the path p is ignored by matchers.
+// Matchers are evaluated in order and later matchers take precedence over earlier ones,
+// so matchers of parent directories must precede matchers of subdirectories.
+func Ignored(matchers []*Matcher, p string, isDir bool) bool {
+	for i := len(matchers) - 1; i >= 0; i-- {
+		if ignored, matched := matchers[i].Match(p, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
+
+// compilePattern compiles a line of an ignore file.
+// If the line is blank or a comment, compilePattern returns nil.
+func compilePattern(line string) (*pattern, error) {
+	line = strings.TrimRight(line, "\r")
+	line = strings.TrimRight(line, " ")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil //nolint:nilnil
+	}
+	p := &pattern{}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil //nolint:nilnil
+	}
+	// A pattern including a slash is relative to the directory of the ignore file.
+	// Otherwise, the pattern matches a name at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("compile a pattern: %w", err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// globToRegexp converts a glob pattern to a regular expression.
+// "**" matches any number of directories.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	segmentStart := true
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		rest := glob[i:]
+		switch {
+		case segmentStart && strings.HasPrefix(rest, "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+			continue
+		case segmentStart && rest == "**":
+			b.WriteString(".*")
+			return b.String()
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				break
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+		segmentStart = c == '/'
+	}
+	return b.String()
+}
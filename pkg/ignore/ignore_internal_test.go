@@ -0,0 +1,89 @@
+package ignore_test
+
+import (
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/ignore"
+)
+
+func TestIgnored(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string][]string
+		path  string
+		isDir bool
+		exp   bool
+	}{
+		{
+			name:  "name at any depth",
+			files: map[string][]string{".": {"tmp"}},
+			path:  "foo/bar/tmp",
+			exp:   true,
+		},
+		{
+			name:  "anchored",
+			files: map[string][]string{".": {"/tmp"}},
+			path:  "foo/tmp",
+		},
+		{
+			name:  "directory only",
+			files: map[string][]string{".": {"tmp/"}},
+			path:  "tmp",
+		},
+		{
+			name:  "double star",
+			files: map[string][]string{".": {"foo/**/*.tf"}},
+			path:  "foo/bar/baz/main.tf",
+			exp:   true,
+		},
+		{
+			name:  "character class",
+			files: map[string][]string{".": {"main[!_].tf"}},
+			path:  "main_.tf",
+		},
+		{
+			name:  "negation",
+			files: map[string][]string{".": {"*.tf", "!main.tf"}},
+			path:  "main.tf",
+		},
+		{
+			name:  "nested negation",
+			files: map[string][]string{".": {"*.tf"}, "foo": {"!main.tf"}},
+			path:  "foo/main.tf",
+		},
+		{
+			name:  "nested file is relative to its directory",
+			files: map[string][]string{".": {}, "foo": {"/bar"}},
+			path:  "foo/bar",
+			isDir: true,
+			exp:   true,
+		},
+		{
+			name:  "escape",
+			files: map[string][]string{".": {`\#foo`}},
+			path:  "#foo",
+			exp:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			matchers := []*ignore.Matcher{}
+			for _, dir := range []string{".", "foo"} {
+				lines, ok := tt.files[dir]
+				if !ok {
+					continue
+				}
+				m, err := ignore.Compile(dir, lines)
+				if err != nil {
+					t.Fatal(err)
+				}
+				matchers = append(matchers, m)
+			}
+			if got := ignore.Ignored(matchers, tt.path, tt.isDir); got != tt.exp {
+				t.Fatalf("wanted %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// files is a list of files of gitignore style patterns.
+// Patterns in .tfmvignore take precedence over ones in .gitignore in the same directory.
+var files = []string{".gitignore", ".tfmvignore"} //nolint:gochecknoglobals
+
+// dirs is a list of directory names which are always ignored.
+var dirs = map[string]struct{}{ //nolint:gochecknoglobals
+	".git":         {},
+	".terraform":   {},
+	"node_modules": {},
+}
+
+// Walker walks directory trees skipping files and directories ignored by .gitignore, .tfmvignore, and patterns.
+// Ignore files in ancestor directories of the walked directory up to the current directory are also applied.
+// Walker can be used concurrently.
+type Walker struct {
+	fs afero.Fs
+	// root is a matcher of patterns given by the option.
+	// They take precedence over ignore files.
+	root  *Matcher
+	mutex sync.Mutex
+	// matchers is a cache of matchers of each directory.
+	// Matchers of parent directories precede ones of subdirectories.
+	matchers map[string][]*Matcher
+}
+
+// NewWalker creates a Walker.
+// patterns are gitignore style patterns relative to the current directory.
+func NewWalker(afs afero.Fs, patterns []string) (*Walker, error) {
+	root, err := Compile(".", patterns)
+	if err != nil {
+		return nil, fmt.Errorf("compile ignore patterns: %w", err)
+	}
+	return &Walker{
+		fs:       afs,
+		root:     root,
+		matchers: map[string][]*Matcher{},
+	}, nil
+}
+
+// Walk walks the directory tree of root and calls fn for each file and directory which isn't ignored.
+// root itself is never ignored.
+// If fn returns fs.SkipDir for a directory, the directory is skipped.
+func (w *Walker) Walk(root string, fn func(path string, d fs.DirEntry) error) error {
+	return fs.WalkDir(afero.NewIOFS(w.fs), root, func(path string, d fs.DirEntry, err error) error { //nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+		if path != root {
+			if _, ok := dirs[d.Name()]; ok && d.IsDir() {
+				return fs.SkipDir
+			}
+			matchers, err := w.dirMatchers(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			// patterns given by the option take precedence over ignore files
+			if Ignored(append(matchers, w.root), path, d.IsDir()) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+		return fn(path, d)
+	})
+}
+
+// dirMatchers returns matchers of ignore files in dir and its ancestors.
+func (w *Walker) dirMatchers(dir string) ([]*Matcher, error) {
+	dir = filepath.Clean(dir)
+	w.mutex.Lock()
+	matchers, ok := w.matchers[dir]
+	w.mutex.Unlock()
+	if ok {
+		return matchers, nil
+	}
+	var parent []*Matcher
+	if p := filepath.Dir(dir); dir != "." && p != dir {
+		arr, err := w.dirMatchers(p)
+		if err != nil {
+			return nil, err
+		}
+		parent = arr
+	}
+	arr, err := w.readIgnoreFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	matchers = slices.Clip(slices.Concat(parent, arr))
+	w.mutex.Lock()
+	w.matchers[dir] = matchers
+	w.mutex.Unlock()
+	return matchers, nil
+}
+
+// readIgnoreFiles reads ignore files in a directory.
+func (w *Walker) readIgnoreFiles(dir string) ([]*Matcher, error) {
+	matchers := []*Matcher{}
+	for _, name := range files {
+		file := filepath.Join(dir, name)
+		b, err := afero.ReadFile(w.fs, file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read an ignore file: %w", slogerr.With(err, "file", file))
+		}
+		m, err := Parse(dir, b)
+		if err != nil {
+			return nil, fmt.Errorf("parse an ignore file: %w", slogerr.With(err, "file", file))
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
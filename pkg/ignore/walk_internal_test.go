@@ -0,0 +1,77 @@
+package ignore_test
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/ignore"
+)
+
+func TestWalker_Walk(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		".gitignore":              "vendor/\n",
+		"main.tf":                 "",
+		"vendor/main.tf":          "",
+		"foo/.tfmvignore":         "old.tf\n",
+		"foo/main.tf":             "",
+		"foo/old.tf":              "",
+		"foo/bar/old.tf":          "",
+		"foo/bar/vendor/main.tf":  "",
+		"foo/baz/main.tf":         "",
+		"foo/.terraform/main.tf":  "",
+		"foo/node_modules/mod.tf": "",
+	}
+	tests := []struct {
+		name     string
+		root     string
+		patterns []string
+		exp      []string
+	}{
+		{
+			name: "current directory",
+			root: ".",
+			exp: []string{
+				".", ".gitignore", "foo", "foo/.tfmvignore", "foo/bar", "foo/baz", "foo/baz/main.tf", "foo/main.tf", "main.tf",
+			},
+		},
+		{
+			name: "ignore files in ancestor directories are applied",
+			root: "foo/bar",
+			exp:  []string{"foo/bar"},
+		},
+		{
+			name:     "patterns",
+			root:     "foo",
+			patterns: []string{"baz"},
+			exp:      []string{"foo", "foo/.tfmvignore", "foo/bar", "foo/main.tf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			afs := afero.NewMemMapFs()
+			for path, content := range files {
+				if err := afero.WriteFile(afs, path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			walker, err := ignore.NewWalker(afs, tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths := []string{}
+			if err := walker.Walk(tt.root, func(path string, _ fs.DirEntry) error {
+				paths = append(paths, path)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(paths, tt.exp) {
+				t.Fatalf("wanted %v, got %v", tt.exp, paths)
+			}
+		})
+	}
+}
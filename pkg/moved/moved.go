@@ -0,0 +1,82 @@
+// Package moved handles moved blocks in Terraform configuration files.
+package moved
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// Block is a moved block.
+type Block struct {
+	// File is a file path where the moved block is defined.
+	File string `json:"file"`
+	// Line is a line number where the moved block is defined.
+	Line int `json:"line"`
+	// From is the from address as written in the file.
+	From string `json:"from"`
+	// To is the to address as written in the file.
+	To string `json:"to"`
+}
+
+// Find returns moved blocks in *.tf in a directory.
+// Moved blocks are sorted by file and line.
+func Find(fs afero.Fs, dir string) ([]*Block, error) {
+	files, err := afero.Glob(fs, filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("find files: %w", err)
+	}
+	blocks := []*Block{}
+	for _, file := range files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", file))
+		}
+		arr, err := parse(b, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse a file: %w", slogerr.With(err, "file", file))
+		}
+		blocks = append(blocks, arr...)
+	}
+	return blocks, nil
+}
+
+// parse returns moved blocks in a file.
+// Moved blocks without from or to are ignored.
+func parse(src []byte, filePath string) ([]*Block, error) {
+	file, diags := hclsyntax.ParseConfig(src, filePath, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("convert file body to body type")
+	}
+	blocks := []*Block{}
+	for _, block := range body.Blocks {
+		if block.Type != "moved" {
+			continue
+		}
+		from, ok := block.Body.Attributes["from"]
+		if !ok {
+			continue
+		}
+		to, ok := block.Body.Attributes["to"]
+		if !ok {
+			continue
+		}
+		blocks = append(blocks, &Block{
+			File: filePath,
+			Line: block.DefRange().Start.Line,
+			From: strings.TrimSpace(string(from.Expr.Range().SliceBytes(src))),
+			To:   strings.TrimSpace(string(to.Expr.Range().SliceBytes(src))),
+		})
+	}
+	return blocks, nil
+}
@@ -0,0 +1,137 @@
+package moved
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// IsStale returns true if the moved block is no longer needed.
+// A moved block is stale if the from address doesn't exist in the state and the to address exists.
+func (b *Block) IsStale(state *State) bool {
+	return !state.Has(b.From) && state.Has(b.To)
+}
+
+// Prune removes stale moved blocks in *.tf in a directory and returns removed blocks.
+// Files are rewritten by hclwrite, so remaining blocks and comments keep their formatting.
+// If a file becomes empty, the file is removed.
+// If dryRun is true, files aren't changed.
+func Prune(fs afero.Fs, dir string, state *State, dryRun bool) ([]*Block, error) {
+	files, err := afero.Glob(fs, filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("find files: %w", err)
+	}
+	pruned := []*Block{}
+	for _, file := range files {
+		arr, err := pruneFile(fs, file, state, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("prune moved blocks: %w", slogerr.With(err, "file", file))
+		}
+		pruned = append(pruned, arr...)
+	}
+	return pruned, nil
+}
+
+// pruneFile removes stale moved blocks in a file and returns removed blocks.
+func pruneFile(fs afero.Fs, file string, state *State, dryRun bool) ([]*Block, error) {
+	src, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("read a file: %w", err)
+	}
+	blocks, err := parse(src, file)
+	if err != nil {
+		return nil, fmt.Errorf("parse a file: %w", err)
+	}
+	stale := map[int]*Block{}
+	for _, block := range blocks {
+		if block.IsStale(state) {
+			stale[block.Line] = block
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	f, diags := hclwrite.ParseConfig(src, file, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	// hclwrite doesn't expose positions of blocks, so blocks are matched with hclsyntax blocks by order
+	syntaxFile, _ := hclsyntax.ParseConfig(src, file, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	body, ok := syntaxFile.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("convert file body to body type")
+	}
+	writeBlocks := f.Body().Blocks()
+	if len(writeBlocks) != len(body.Blocks) {
+		return nil, errors.New("the number of blocks is different between hclwrite and hclsyntax")
+	}
+	pruned := make([]*Block, 0, len(stale))
+	for i, block := range writeBlocks {
+		if block.Type() != "moved" {
+			continue
+		}
+		b, ok := stale[body.Blocks[i].DefRange().Start.Line]
+		if !ok {
+			continue
+		}
+		f.Body().RemoveBlock(block)
+		pruned = append(pruned, b)
+	}
+	if dryRun {
+		return pruned, nil
+	}
+
+	content := removeBlankLines(f.BuildTokens(nil)).Bytes()
+	if strings.TrimSpace(string(content)) == "" {
+		if err := fs.Remove(file); err != nil {
+			return nil, fmt.Errorf("remove an empty file: %w", err)
+		}
+		return pruned, nil
+	}
+	stat, err := fs.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("get a file stat: %w", err)
+	}
+	if err := afero.WriteFile(fs, file, content, stat.Mode()); err != nil {
+		return nil, fmt.Errorf("write a file: %w", err)
+	}
+	return pruned, nil
+}
+
+// removeBlankLines removes blank lines left by removed blocks.
+// Consecutive blank lines are reduced to one, and leading and trailing blank lines are removed.
+// Tokens are written as is rather than by hclwrite.File.Bytes, which formats the whole file.
+func removeBlankLines(tokens hclwrite.Tokens) hclwrite.Tokens {
+	ret := make(hclwrite.Tokens, 0, len(tokens))
+	// lineStart is true if the next token starts a line
+	lineStart := true
+	// blank is true if the last token is a blank line
+	blank := true
+	for _, token := range tokens {
+		switch token.Type { //nolint:exhaustive
+		case hclsyntax.TokenNewline:
+			if lineStart && blank {
+				continue
+			}
+			blank = lineStart
+			lineStart = true
+		case hclsyntax.TokenEOF:
+			if blank && len(ret) != 0 {
+				ret = ret[:len(ret)-1]
+			}
+		default:
+			blank = false
+			lineStart = token.Type == hclsyntax.TokenComment && strings.HasSuffix(string(token.Bytes), "\n")
+		}
+		ret = append(ret, token)
+	}
+	return ret
+}
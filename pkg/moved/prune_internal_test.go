@@ -0,0 +1,143 @@
+package moved_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/moved"
+)
+
+func TestPrune(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	tests := []struct {
+		name   string
+		states []string
+		src    string
+		exp    string
+		pruned int
+	}{
+		{
+			name: "terraform state list",
+			states: []string{`null_resource.foo_1
+module.bar_1.null_resource.baz
+`},
+			src: `# moved blocks
+
+moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+
+# keep this block because the old resource remains
+moved {
+  from = null_resource.foo-2
+  to = null_resource.foo_2
+}
+
+moved {
+  from = module.bar-1
+  to   = module.bar_1
+}
+`,
+			exp: `# moved blocks
+
+# keep this block because the old resource remains
+moved {
+  from = null_resource.foo-2
+  to = null_resource.foo_2
+}
+`,
+			pruned: 2,
+		},
+		{
+			name: "terraform show -json",
+			states: []string{`{
+  "format_version": "1.0",
+  "values": {
+    "root_module": {
+      "child_modules": [
+        {
+          "address": "module.bar_1[\"a\"]",
+          "resources": [
+            {"address": "module.bar_1[\"a\"].null_resource.baz"}
+          ]
+        }
+      ]
+    }
+  }
+}`, `{"format_version": "1.0"}`},
+			src: `moved {
+  from = module.bar-1
+  to   = module.bar_1
+}
+`,
+			pruned: 1,
+		},
+		{
+			name: "the old address exists in another state",
+			states: []string{
+				"null_resource.foo_1[0]\n",
+				"null_resource.foo-1[0]\n",
+			},
+			src: `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			exp: `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+		},
+		{
+			name:   "the new address doesn't exist",
+			states: []string{"null_resource.foo_10\n"},
+			src: `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			exp: `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			state := moved.NewState()
+			for _, s := range tt.states {
+				if err := state.Read([]byte(s)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, "moved.tf", []byte(tt.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			pruned, err := moved.Prune(fs, ".", state, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pruned) != tt.pruned {
+				t.Fatalf("wanted %d pruned blocks, got %d", tt.pruned, len(pruned))
+			}
+			b, err := afero.ReadFile(fs, "moved.tf")
+			if tt.exp == "" {
+				if err == nil {
+					t.Fatal("moved.tf should be removed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, string(b))
+			}
+		})
+	}
+}
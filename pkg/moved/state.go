@@ -0,0 +1,112 @@
+package moved
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// State is a set of resource addresses in Terraform states.
+type State struct {
+	addrs map[string]struct{}
+}
+
+// NewState creates an empty State.
+func NewState() *State {
+	return &State{
+		addrs: map[string]struct{}{},
+	}
+}
+
+// ReadStateFiles reads state files and returns a State including resources of all states.
+func ReadStateFiles(fs afero.Fs, files []string) (*State, error) {
+	state := NewState()
+	for _, file := range files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return nil, fmt.Errorf("read a state file: %w", slogerr.With(err, "file", file))
+		}
+		if err := state.Read(b); err != nil {
+			return nil, fmt.Errorf("read a state: %w", slogerr.With(err, "file", file))
+		}
+	}
+	return state, nil
+}
+
+// Read adds resource addresses in src to the state.
+// src is either the output of `terraform show -json` or `terraform state list`.
+func (s *State) Read(src []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(src), []byte("{")) {
+		return s.readJSON(src)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		if addr := strings.TrimSpace(scanner.Text()); addr != "" {
+			s.addrs[addr] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read the output of terraform state list: %w", err)
+	}
+	return nil
+}
+
+// showJSON is the output of `terraform show -json`.
+type showJSON struct {
+	Values *struct {
+		RootModule *stateModule `json:"root_module"`
+	} `json:"values"`
+}
+
+type stateModule struct {
+	Resources []*struct {
+		Address string `json:"address"`
+	} `json:"resources"`
+	ChildModules []*stateModule `json:"child_modules"`
+}
+
+// readJSON adds resource addresses in the output of `terraform show -json`.
+// An empty state doesn't have values.
+func (s *State) readJSON(src []byte) error {
+	show := &showJSON{}
+	if err := json.Unmarshal(src, show); err != nil {
+		return fmt.Errorf("parse the output of terraform show -json: %w", err)
+	}
+	if show.Values == nil {
+		return nil
+	}
+	s.addModule(show.Values.RootModule)
+	return nil
+}
+
+func (s *State) addModule(mod *stateModule) {
+	if mod == nil {
+		return
+	}
+	for _, resource := range mod.Resources {
+		s.addrs[resource.Address] = struct{}{}
+	}
+	for _, child := range mod.ChildModules {
+		s.addModule(child)
+	}
+}
+
+// Has returns true if the state has the address.
+// An address matches resource instances and resources in module instances,
+// so module.foo matches module.foo["a"].aws_instance.bar and aws_instance.foo matches aws_instance.foo[0].
+func (s *State) Has(addr string) bool {
+	if _, ok := s.addrs[addr]; ok {
+		return true
+	}
+	for a := range s.addrs {
+		if rest, ok := strings.CutPrefix(a, addr); ok && (rest[0] == '.' || rest[0] == '[') {
+			return true
+		}
+	}
+	return false
+}
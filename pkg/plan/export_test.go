@@ -0,0 +1,4 @@
+package plan
+
+// FilterChangedDirs exports filterChangedDirs for tests.
+var FilterChangedDirs = filterChangedDirs //nolint:gochecknoglobals
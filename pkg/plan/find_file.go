@@ -3,47 +3,99 @@ package plan
 import (
 	"fmt"
 	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/git"
+	"github.com/suzuki-shunsuke/tfmv/pkg/ignore"
 )
 
-func (c *Planner) findFiles(input *domain.Input) ([]string, error) {
+func (c *Planner) findFiles(logger *slog.Logger, input *domain.Input) ([]string, error) {
 	if len(input.Args) != 0 {
 		return input.Args, nil
 	}
+	if len(input.Changes) != 0 {
+		return c.changedDirFiles(input.Changes)
+	}
+	files, err := c.discoverFiles(logger, input)
+	if err != nil {
+		return nil, err
+	}
+	if input.ChangedSince == "" {
+		return files, nil
+	}
+	changed, err := git.ChangedFiles(".", input.ChangedSince)
+	if err != nil {
+		return nil, fmt.Errorf("get changed files: %w", err)
+	}
+	return filterChangedDirs(files, changed), nil
+}
+
+// discoverFiles finds *.tf by root modules, recursive discovery, or the current directory.
+func (c *Planner) discoverFiles(logger *slog.Logger, input *domain.Input) ([]string, error) {
+	if len(input.RootModules) != 0 {
+		return c.moduleTreeFiles(logger, input.RootModules, input.Tolerant)
+	}
 	if input.Recursive {
-		return c.walkFiles()
+		return c.walkFiles(input.Ignore)
 	}
 	return afero.Glob(c.fs, "*.tf") //nolint:wrapcheck
 }
 
-func (c *Planner) walkFiles() ([]string, error) {
-	// find *.tf
-	ignoreDirs := map[string]struct{}{
-		".git":         {},
-		".terraform":   {},
-		"node_modules": {},
+// walkFiles finds *.tf recursively.
+// Files and directories ignored by .gitignore, .tfmvignore, and patterns are skipped.
+func (c *Planner) walkFiles(patterns []string) ([]string, error) {
+	walker, err := ignore.NewWalker(c.fs, patterns)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
 	}
 	files := []string{}
-	if err := fs.WalkDir(afero.NewIOFS(c.fs), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if _, ok := ignoreDirs[d.Name()]; ok {
-			return fs.SkipDir
+	if err := walker.Walk(".", func(path string, d fs.DirEntry) error {
+		if !d.IsDir() && strings.HasSuffix(path, ".tf") {
+			files = append(files, path)
 		}
-		if d.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(path, ".tf") {
-			return nil
-		}
-		files = append(files, path)
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("walk a directory: %w", err)
 	}
 	return files, nil
 }
+
+// changedDirFiles returns *.tf in directories of given changes.
+func (c *Planner) changedDirFiles(changes []*domain.Change) ([]string, error) {
+	dirs := map[string]struct{}{}
+	files := []string{}
+	for _, change := range changes {
+		dir := filepath.Clean(change.Dir)
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		dirs[dir] = struct{}{}
+		arr, err := afero.Glob(c.fs, filepath.Join(dir, "*.tf"))
+		if err != nil {
+			return nil, fmt.Errorf("find files: %w", err)
+		}
+		files = append(files, arr...)
+	}
+	return files, nil
+}
+
+// filterChangedDirs returns files in directories including changed *.tf.
+// changed is a list of slash separated file paths given by git.
+// Files in other directories are excluded, so --changed-since narrows files found by discoverFiles.
+func filterChangedDirs(files, changed []string) []string {
+	dirs := map[string]struct{}{}
+	for _, file := range changed {
+		if strings.HasSuffix(file, ".tf") {
+			dirs[filepath.Dir(filepath.FromSlash(file))] = struct{}{}
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(files), func(file string) bool {
+		_, ok := dirs[filepath.Dir(file)]
+		return !ok
+	})
+}
@@ -0,0 +1,51 @@
+package plan_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/plan"
+)
+
+func TestFilterChangedDirs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		files   []string
+		changed []string
+		exp     []string
+	}{
+		{
+			name:    "current directory",
+			files:   []string{"main.tf", "outputs.tf"},
+			changed: []string{"main.tf", "foo/main.tf"},
+			exp:     []string{"main.tf", "outputs.tf"},
+		},
+		{
+			name:    "changed subdirectories aren't added",
+			files:   []string{"main.tf"},
+			changed: []string{"foo/main.tf"},
+			exp:     []string{},
+		},
+		{
+			name:    "recursive",
+			files:   []string{"main.tf", "foo/main.tf", "foo/outputs.tf", "bar/main.tf", "bar/baz/main.tf"},
+			changed: []string{"foo/outputs.tf", "bar/README.md", "bar/baz/main.tf", "ignored/main.tf"},
+			exp:     []string{"foo/main.tf", "foo/outputs.tf", "bar/baz/main.tf"},
+		},
+		{
+			name:    "root modules",
+			files:   []string{"./root/main.tf", "modules/foo/main.tf"},
+			changed: []string{"root/main.tf"},
+			exp:     []string{"./root/main.tf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := plan.FilterChangedDirs(tt.files, tt.changed); !slices.Equal(got, tt.exp) {
+				t.Fatalf("wanted %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// moduleTreeFiles returns *.tf in root module directories and local modules called from them transitively.
+// A local module is a module whose source starts with "./" or "../".
+// Each directory is processed only once, so cyclic module calls are allowed.
+// In tolerant mode, local modules called from directories including files which can't be parsed aren't followed,
+// and the directories are skipped when the files are parsed.
+func (c *Planner) moduleTreeFiles(logger *slog.Logger, roots []string, tolerant bool) ([]string, error) {
+	visited := map[string]struct{}{}
+	queue := make([]string, 0, len(roots))
+	for _, root := range roots {
+		queue = append(queue, filepath.Clean(root))
+	}
+	files := []string{}
+	for len(queue) != 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[dir]; ok {
+			continue
+		}
+		visited[dir] = struct{}{}
+		if ok, err := afero.DirExists(c.fs, dir); err != nil {
+			return nil, fmt.Errorf("check if a module directory exists: %w", slogerr.With(err, "dir", dir))
+		} else if !ok {
+			return nil, slogerr.With(errors.New("a module directory isn't found"), "dir", dir) //nolint:wrapcheck
+		}
+		arr, err := afero.Glob(c.fs, filepath.Join(dir, "*.tf"))
+		if err != nil {
+			return nil, fmt.Errorf("find files: %w", err)
+		}
+		modules, err := c.localModules(logger, dir, arr, tolerant)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, modules...)
+		files = append(files, arr...)
+	}
+	return files, nil
+}
+
+// localModules returns directories of local modules called in files of a directory.
+// In tolerant mode, if any file can't be parsed, localModules returns nothing because the directory is skipped.
+func (c *Planner) localModules(logger *slog.Logger, dir string, files []string, tolerant bool) ([]string, error) {
+	modules := []string{}
+	for _, file := range files {
+		b, err := afero.ReadFile(c.fs, file)
+		if err != nil {
+			return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", file))
+		}
+		sources, err := localModuleSources(b, file)
+		if err != nil {
+			diags := hcl.Diagnostics{}
+			if tolerant && errors.As(err, &diags) {
+				slogerr.WithError(logger, err).Debug("local modules aren't followed because a file can't be parsed", "file", file)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("find local modules: %w", slogerr.With(err, "file", file))
+		}
+		for _, source := range sources {
+			modules = append(modules, filepath.Join(dir, filepath.FromSlash(source)))
+		}
+	}
+	return modules, nil
+}
+
+// localModuleSources returns sources of local modules called in a file.
+// Sources which aren't string literals are ignored.
+func localModuleSources(src []byte, filePath string) ([]string, error) {
+	file, diags := hclsyntax.ParseConfig(src, filePath, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("convert file body to body type")
+	}
+	sources := []string{}
+	for _, block := range body.Blocks {
+		if block.Type != "module" {
+			continue
+		}
+		attr, ok := block.Body.Attributes["source"]
+		if !ok {
+			continue
+		}
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || v.Type() != cty.String || !v.IsKnown() || v.IsNull() {
+			continue
+		}
+		source := v.AsString()
+		if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
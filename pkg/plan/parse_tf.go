@@ -39,6 +39,7 @@ func parseBlock(filePath string, block *hclsyntax.Block, include, exclude *regex
 	}
 	b := &domain.Block{
 		File:      filePath,
+		Line:      block.DefRange().Start.Line,
 		BlockType: block.Type,
 	}
 	switch len(block.Labels) {
@@ -4,13 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"path/filepath"
+	"slices"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/spf13/afero"
 	"github.com/suzuki-shunsuke/slog-error/slogerr"
 	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
 	"github.com/suzuki-shunsuke/tfmv/pkg/rename"
+	"golang.org/x/sync/errgroup"
 )
 
 type Planner struct {
@@ -23,29 +27,28 @@ func NewPlanner(fs afero.Fs) *Planner {
 	}
 }
 
-func (c *Planner) Plan(logger *slog.Logger, input *domain.Input) (map[string]*domain.Dir, error) {
+// Plan finds and parses files and returns renamed blocks in each directory.
+// In tolerant mode, directories including files which can't be parsed are skipped and returned separately.
+func (c *Planner) Plan(logger *slog.Logger, input *domain.Input) (map[string]*domain.Dir, []*domain.SkippedDir, error) {
 	renamer, err := rename.New(logger, c.fs, input)
 	if err != nil {
-		return nil, fmt.Errorf("initialize a renamer: %w", err)
+		return nil, nil, fmt.Errorf("initialize a renamer: %w", err)
 	}
 
 	// find *.tf
 	logger.Debug("finding tf files")
-	files, err := c.findFiles(input)
+	files, err := c.findFiles(logger, input)
 	if err != nil {
-		return nil, fmt.Errorf("find a file: %w", err)
+		return nil, nil, fmt.Errorf("find a file: %w", err)
 	}
 	if len(files) == 0 {
 		logger.Warn("no tf file is found")
-		return nil, nil //nolint:nilnil
+		return nil, nil, validate(renamer)
 	}
 	logger.Debug("found tf files", "num_of_files", len(files))
 
-	// read *.tf
 	dirs := map[string]*domain.Dir{}
 	for _, file := range files {
-		logger := logger.With("file", file)
-		logger.Debug("handling a file")
 		dirPath := filepath.Dir(file)
 		dir, ok := dirs[dirPath]
 		if !ok {
@@ -53,18 +56,154 @@ func (c *Planner) Plan(logger *slog.Logger, input *domain.Input) (map[string]*do
 			dirs[dirPath] = dir
 		}
 		dir.Files = append(dir.Files, file)
-		blocks, err := c.handleFile(logger, renamer, input, file)
+	}
+
+	// read *.tf
+	blocks, skipped, err := c.handleFiles(logger, input, files)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range skipped {
+		delete(dirs, dir.Dir)
+	}
+
+	// rename blocks
+	newNames, err := renameBlocks(logger, renamer, blocks, input.Workers())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, block := range blocks {
+		newName := newNames[i]
+		if newName == "" || newName == block.Name {
+			continue
+		}
+		if !hclsyntax.ValidIdentifier(newName) {
+			return nil, nil, slogerr.With(errors.New("the new name is an invalid HCL identifier"), "address", block.TFAddress, "new_name", newName) //nolint:wrapcheck
+		}
+		block.SetNewName(newName)
+		if block.Rule != nil && block.Rule.MovedFile != "" {
+			block.MovedFile = getMovedFile(block.File, block.Rule.MovedFile)
+		}
+		dir := dirs[filepath.Dir(block.File)]
+		dir.Blocks = append(dir.Blocks, block)
+	}
+	if err := validate(renamer); err != nil {
+		return nil, nil, err
+	}
+	return dirs, skipped, nil
+}
+
+// renameBlocks returns new names of blocks.
+// The i-th new name corresponds to the i-th block.
+// If the renamer implements rename.BatchRenamer and works in batch mode, all blocks are renamed at once.
+// Otherwise, blocks are renamed concurrently by at most workers goroutines
+// because renamers such as external commands, Jsonnet, and Starlark may be slow.
+func renameBlocks(logger *slog.Logger, renamer rename.Renamer, blocks []*domain.Block, workers int) ([]string, error) {
+	if br, ok := renamer.(rename.BatchRenamer); ok && br.Batch() {
+		logger.Debug("renaming blocks at once", "num_of_blocks", len(blocks))
+		newNames, err := br.RenameBlocks(blocks)
 		if err != nil {
-			return nil, fmt.Errorf("handle a file: %w", slogerr.With(err, "file", file))
+			return nil, fmt.Errorf("get new names: %w", err)
+		}
+		return newNames, nil
+	}
+	newNames := make([]string, len(blocks))
+	eg := &errgroup.Group{}
+	eg.SetLimit(workers)
+	for i, block := range blocks {
+		eg.Go(func() error {
+			logger.Debug("handling a block",
+				"file", block.File,
+				"block_type", block.BlockType,
+				"resource_type", block.ResourceType,
+				"name", block.Name,
+			)
+			newName, err := renamer.Rename(block)
+			if err != nil {
+				return fmt.Errorf("get a new name: %w", slogerr.With(err, "file", block.File, "address", block.TFAddress))
+			}
+			newNames[i] = newName
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return newNames, nil
+}
+
+// validate validates the result of renaming if the renamer implements rename.Validator.
+func validate(renamer rename.Renamer) error {
+	v, ok := renamer.(rename.Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("validate renamed blocks: %w", err)
+	}
+	return nil
+}
+
+// handleFiles reads and parses files concurrently and returns blocks.
+// Blocks are returned in order of files regardless of the order of processing.
+// In tolerant mode, directories including files which can't be parsed are returned as skipped directories,
+// and blocks in them aren't returned.
+func (c *Planner) handleFiles(logger *slog.Logger, input *domain.Input, files []string) ([]*domain.Block, []*domain.SkippedDir, error) {
+	blocksOfFiles := make([][]*domain.Block, len(files))
+	diagsOfFiles := make([][]*domain.Diagnostic, len(files))
+	eg := &errgroup.Group{}
+	eg.SetLimit(input.Workers())
+	for i, file := range files {
+		eg.Go(func() error {
+			logger := logger.With("file", file)
+			logger.Debug("handling a file")
+			arr, err := c.handleFile(logger, input, file)
+			if err != nil {
+				diags := hcl.Diagnostics{}
+				if input.Tolerant && errors.As(err, &diags) {
+					slogerr.WithError(logger, err).Warn("skip a directory because a file can't be parsed", "dir", filepath.Dir(file))
+					diagsOfFiles[i] = domain.NewDiagnostics(file, diags)
+					return nil
+				}
+				return fmt.Errorf("handle a file: %w", slogerr.With(err, "file", file))
+			}
+			blocksOfFiles[i] = arr
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	skipped := map[string]*domain.SkippedDir{}
+	for i, file := range files {
+		if diagsOfFiles[i] == nil {
+			continue
+		}
+		dir := filepath.Dir(file)
+		s, ok := skipped[dir]
+		if !ok {
+			s = &domain.SkippedDir{Dir: dir}
+			skipped[dir] = s
+		}
+		s.Diagnostics = append(s.Diagnostics, diagsOfFiles[i]...)
+	}
+	blocks := []*domain.Block{}
+	for _, block := range slices.Concat(blocksOfFiles...) {
+		if _, ok := skipped[filepath.Dir(block.File)]; !ok {
+			blocks = append(blocks, block)
 		}
-		dir.Blocks = append(dir.Blocks, blocks...)
 	}
-	return dirs, nil
+	skippedDirs := make([]*domain.SkippedDir, 0, len(skipped))
+	for _, dir := range slices.Sorted(maps.Keys(skipped)) {
+		skippedDirs = append(skippedDirs, skipped[dir])
+	}
+	return blocks, skippedDirs, nil
 }
 
-// handleFile reads and parses a file and returns renamed blocks.
+// handleFile reads and parses a file and returns blocks.
 // handleFile doesn't actually edit a file.
-func (c *Planner) handleFile(logger *slog.Logger, renamer rename.Renamer, input *domain.Input, file string) ([]*domain.Block, error) {
+func (c *Planner) handleFile(logger *slog.Logger, input *domain.Input, file string) ([]*domain.Block, error) {
 	logger.Debug("reading a tf file")
 	b, err := afero.ReadFile(c.fs, file)
 	if err != nil {
@@ -79,30 +218,11 @@ func (c *Planner) handleFile(logger *slog.Logger, renamer rename.Renamer, input
 		logger.Debug("no resource or module block is found")
 		return nil, nil
 	}
-	arr := []*domain.Block{}
 	movedFile := getMovedFile(file, input.MovedFile)
 	for _, block := range blocks {
-		logger := logger.With(
-			"block_type", block.BlockType,
-			"resource_type", block.ResourceType,
-			"name", block.Name,
-		)
-		logger.Debug("handling a block")
 		block.MovedFile = movedFile
-		newName, err := renamer.Rename(block)
-		if err != nil {
-			return nil, fmt.Errorf("get a new name: %w", err)
-		}
-		if newName == "" || newName == block.Name {
-			continue
-		}
-		if !hclsyntax.ValidIdentifier(newName) {
-			return nil, slogerr.With(errors.New("the new name is an invalid HCL identifier"), "address", block.TFAddress, "new_name", newName) //nolint:wrapcheck
-		}
-		block.SetNewName(newName)
-		arr = append(arr, block)
 	}
-	return arr, nil
+	return blocks, nil
 }
 
 // getMovedFile returns a file path where moved blocks are written.
@@ -0,0 +1,71 @@
+package plan_test
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/plan"
+)
+
+func TestPlanner_Plan_concurrent(t *testing.T) {
+	t.Parallel()
+	const numOfBlocks = 50
+	content := &strings.Builder{}
+	changes := make([]*domain.Change, numOfBlocks)
+	exp := make([]string, numOfBlocks)
+	for i := range numOfBlocks {
+		fmt.Fprintf(content, "resource \"null_resource\" \"foo-%d\" {}\n", i)
+		changes[i] = &domain.Change{
+			Dir:        ".",
+			Address:    fmt.Sprintf("null_resource.foo-%d", i),
+			NewAddress: fmt.Sprintf("null_resource.foo_%d", i),
+		}
+		exp[i] = fmt.Sprintf("null_resource.foo-%d -> null_resource.foo_%d", i, i)
+	}
+	tests := []struct {
+		name  string
+		input *domain.Input
+	}{
+		{
+			name: "replace",
+			input: &domain.Input{
+				Replace:     "-/_",
+				Parallelism: 4,
+			},
+		},
+		{
+			name: "changes",
+			input: &domain.Input{
+				Changes:     changes,
+				Parallelism: 4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, "main.tf", []byte(content.String()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dirs, _, err := plan.NewPlanner(fs).Plan(slog.New(slog.DiscardHandler), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Blocks must be in order of the file regardless of the order of renaming
+			blocks := dirs["."].Blocks
+			if len(blocks) != len(exp) {
+				t.Fatalf("wanted %d blocks, got %d", len(exp), len(blocks))
+			}
+			for i, block := range blocks {
+				if got := block.TFAddress + " -> " + block.NewTFAddress; got != exp[i] {
+					t.Fatalf("block %d: wanted %q, got %q", i, exp[i], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package planfile
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// formatVersion is a version of the plan file format.
+// It must be incremented when the format is changed incompatibly.
+const formatVersion = 2
+
+// Plan is a serialisable plan.
+// It is created by `tfmv plan` and applied by `tfmv apply`.
+type Plan struct {
+	// FormatVersion is a version of the plan file format.
+	FormatVersion int `json:"format_version"`
+	// Args is a list of files passed as arguments when the plan was created.
+	Args []string `json:"args,omitempty"`
+	// Settings is options which affect files changed by the plan.
+	Settings *Settings `json:"settings"`
+	// Dirs is a list of directories sorted by path.
+	Dirs []*Dir `json:"dirs"`
+	// SkippedDirs is a list of directories skipped in tolerant mode.
+	// Files in them aren't changed when the plan is applied.
+	SkippedDirs []string `json:"skipped_dirs,omitempty"`
+	// Inputs is a list of hashes of Jsonnet and Starlark files which renamed blocks.
+	Inputs []*FileHash `json:"inputs,omitempty"`
+}
+
+// Settings is options which affect files changed by the plan.
+// They are fixed when the plan is created, so the same plan always changes the same files.
+type Settings struct {
+	Emit          []string `json:"emit"`
+	StateMvFile   string   `json:"state_mv_file"`
+	TFMigrateFile string   `json:"tfmigrate_file"`
+	RefScope      string   `json:"ref_scope"`
+}
+
+func newSettings(input *domain.Input) *Settings {
+	s := &Settings{
+		Emit:          slices.Sorted(slices.Values(input.Emit)),
+		StateMvFile:   input.StateMvFile,
+		TFMigrateFile: input.TFMigrateFile,
+		RefScope:      cmp.Or(input.RefScope, domain.RefScopeDir),
+	}
+	if len(s.Emit) == 0 {
+		s.Emit = []string{domain.EmitMoved}
+	}
+	return s
+}
+
+// SetTo sets the settings to input.
+// Empty fields of input are filled by the settings.
+// If a field of input isn't empty and is different from the setting, SetTo returns an error because it conflicts with the plan.
+func (s *Settings) SetTo(input *domain.Input) error {
+	if len(input.Emit) != 0 && !slices.Equal(slices.Sorted(slices.Values(input.Emit)), s.Emit) {
+		return slogerr.With(errors.New("--emit conflicts with the plan"), "emit", input.Emit, "planned_emit", s.Emit) //nolint:wrapcheck
+	}
+	input.Emit = s.Emit
+	fields := []struct {
+		name    string
+		value   *string
+		planned string
+	}{
+		{name: "--state-mv-file", value: &input.StateMvFile, planned: s.StateMvFile},
+		{name: "--tfmigrate-file", value: &input.TFMigrateFile, planned: s.TFMigrateFile},
+		{name: "--ref-scope", value: &input.RefScope, planned: s.RefScope},
+	}
+	for _, f := range fields {
+		if *f.value != "" && *f.value != f.planned {
+			return slogerr.With(errors.New(f.name+" conflicts with the plan"), "value", *f.value, "planned_value", f.planned) //nolint:wrapcheck
+		}
+		*f.value = f.planned
+	}
+	return nil
+}
+
+// Dir is a serialisable domain.Dir.
+type Dir struct {
+	// Path is a directory path.
+	Path string `json:"path"`
+	// Files is a list of file paths handled by the planner.
+	Files []string `json:"files"`
+	// Hashes is a list of hashes of *.tf files in the reference scope of the directory.
+	// tfmv refuses to apply the plan if any file is changed, added, or removed.
+	Hashes []*FileHash `json:"hashes"`
+	// Blocks is a list of renamed blocks.
+	Blocks []*Block `json:"blocks"`
+}
+
+// FileHash is a SHA256 hash of a file content.
+type FileHash struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Block is a serialisable domain.Block.
+type Block struct {
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	BlockType    string `json:"block_type"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Name         string `json:"name"`
+	NewName      string `json:"new_name"`
+	MovedFile    string `json:"moved_file"`
+	Rule         string `json:"rule,omitempty"`
+}
+
+// New creates a Plan from directories.
+// scopeFiles is a map of a directory path to files which may be read or changed when the plan is applied.
+func New(fs afero.Fs, input *domain.Input, dirs map[string]*domain.Dir, skipped []*domain.SkippedDir, scopeFiles map[string][]string) (*Plan, error) {
+	inputs, err := hashFiles(fs, inputFiles(input))
+	if err != nil {
+		return nil, fmt.Errorf("calculate hashes of input files: %w", err)
+	}
+	plan := &Plan{
+		FormatVersion: formatVersion,
+		Args:          input.Args,
+		Settings:      newSettings(input),
+		Dirs:          make([]*Dir, 0, len(dirs)),
+		SkippedDirs:   slices.Sorted(slices.Values(domain.SkippedDirPaths(skipped))),
+		Inputs:        inputs,
+	}
+	for _, dir := range dirs {
+		hashes, err := hashFiles(fs, scopeFiles[dir.Path])
+		if err != nil {
+			return nil, fmt.Errorf("calculate hashes of files: %w", slogerr.With(err, "dir", dir.Path))
+		}
+		d := &Dir{
+			Path:   dir.Path,
+			Files:  dir.Files,
+			Hashes: hashes,
+			Blocks: make([]*Block, len(dir.Blocks)),
+		}
+		for i, block := range dir.Blocks {
+			d.Blocks[i] = &Block{
+				File:         block.File,
+				Line:         block.Line,
+				BlockType:    block.BlockType,
+				ResourceType: block.ResourceType,
+				Name:         block.Name,
+				NewName:      block.NewName,
+				MovedFile:    block.MovedFile,
+			}
+			if block.Rule != nil {
+				d.Blocks[i].Rule = block.Rule.Name
+			}
+		}
+		plan.Dirs = append(plan.Dirs, d)
+	}
+	slices.SortFunc(plan.Dirs, func(a, b *Dir) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return plan, nil
+}
+
+// Read reads a plan file.
+func Read(fs afero.Fs, path string) (*Plan, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read a plan file: %w", err)
+	}
+	plan := &Plan{}
+	if err := json.Unmarshal(b, plan); err != nil {
+		return nil, fmt.Errorf("parse a plan file as JSON: %w", err)
+	}
+	if plan.FormatVersion != formatVersion {
+		return nil, slogerr.With(errors.New("unsupported plan file format version"), "format_version", plan.FormatVersion) //nolint:wrapcheck
+	}
+	if plan.Settings == nil {
+		return nil, errors.New("settings are missing in the plan file")
+	}
+	if err := domain.ValidateEmit(plan.Settings.Emit); err != nil {
+		return nil, fmt.Errorf("validate the plan file: %w", err)
+	}
+	if err := domain.ValidateRefScope(plan.Settings.RefScope); err != nil {
+		return nil, fmt.Errorf("validate the plan file: %w", err)
+	}
+	for _, dir := range plan.Dirs {
+		if err := dir.validatePaths(); err != nil {
+			return nil, fmt.Errorf("validate the plan file: %w", slogerr.With(err, "dir", dir.Path))
+		}
+	}
+	return plan, nil
+}
+
+// validatePaths checks if files of the directory and blocks are directly in the directory,
+// so an edited plan file can't change files outside the directory.
+func (d *Dir) validatePaths() error {
+	paths := slices.Clone(d.Files)
+	for _, block := range d.Blocks {
+		paths = append(paths, block.File, block.MovedFile)
+	}
+	dir := filepath.Clean(d.Path)
+	for _, path := range paths {
+		if filepath.Dir(filepath.Clean(path)) != dir {
+			return slogerr.With(errors.New("a file isn't in the directory"), "file", path) //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// Write writes a plan file.
+func (p *Plan) Write(fs afero.Fs, path string) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode a plan as JSON: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, append(b, '\n'), 0o644); err != nil { //nolint:mnd
+		return fmt.Errorf("write a plan file: %w", err)
+	}
+	return nil
+}
+
+// Verify checks if files haven't been changed since the plan was created.
+// scopeFiles is a map of a directory path to files which may be read or changed when the plan is applied.
+// Jsonnet and Starlark files which renamed blocks are also checked.
+func (p *Plan) Verify(fs afero.Fs, scopeFiles map[string][]string) error {
+	for _, dir := range p.Dirs {
+		hashes, err := hashFiles(fs, scopeFiles[dir.Path])
+		if err != nil {
+			return fmt.Errorf("calculate hashes of files: %w", slogerr.With(err, "dir", dir.Path))
+		}
+		if err := compareHashes(dir.Hashes, hashes); err != nil {
+			return slogerr.With(err, "dir", dir.Path) //nolint:wrapcheck
+		}
+	}
+	paths := make([]string, len(p.Inputs))
+	for i, h := range p.Inputs {
+		paths[i] = h.Path
+	}
+	hashes, err := hashFiles(fs, paths)
+	if err != nil {
+		return fmt.Errorf("calculate hashes of input files: %w", err)
+	}
+	return compareHashes(p.Inputs, hashes)
+}
+
+// compareHashes returns an error if any file is added, changed, or removed.
+func compareHashes(planned, hashes []*FileHash) error {
+	m := make(map[string]string, len(planned))
+	for _, h := range planned {
+		m[h.Path] = h.SHA256
+	}
+	for _, h := range hashes {
+		sum, ok := m[h.Path]
+		if !ok {
+			return slogerr.With(errors.New("a file has been added since the plan was created"), "file", h.Path) //nolint:wrapcheck
+		}
+		if sum != h.SHA256 {
+			return slogerr.With(errors.New("a file has been changed since the plan was created"), "file", h.Path) //nolint:wrapcheck
+		}
+		delete(m, h.Path)
+	}
+	if len(m) != 0 {
+		removed := slices.Sorted(maps.Keys(m))
+		return slogerr.With(errors.New("a file has been removed since the plan was created"), "file", removed[0]) //nolint:wrapcheck
+	}
+	return nil
+}
+
+// ToDirs converts the plan to directories.
+func (p *Plan) ToDirs() (map[string]*domain.Dir, error) {
+	dirs := make(map[string]*domain.Dir, len(p.Dirs))
+	for _, d := range p.Dirs {
+		dir := &domain.Dir{
+			Path:   d.Path,
+			Files:  d.Files,
+			Blocks: make([]*domain.Block, len(d.Blocks)),
+		}
+		for i, b := range d.Blocks {
+			block, err := b.toBlock()
+			if err != nil {
+				return nil, err
+			}
+			dir.Blocks[i] = block
+		}
+		dirs[d.Path] = dir
+	}
+	return dirs, nil
+}
+
+func (b *Block) toBlock() (*domain.Block, error) {
+	if _, ok := domain.Types()[b.BlockType]; !ok {
+		return nil, slogerr.With(errors.New("invalid block type"), "block_type", b.BlockType) //nolint:wrapcheck
+	}
+	if !hclsyntax.ValidIdentifier(b.NewName) {
+		return nil, slogerr.With(errors.New("the new name is an invalid HCL identifier"), "new_name", b.NewName) //nolint:wrapcheck
+	}
+	block := &domain.Block{
+		File:         b.File,
+		Line:         b.Line,
+		BlockType:    b.BlockType,
+		ResourceType: b.ResourceType,
+		Name:         b.Name,
+		MovedFile:    b.MovedFile,
+	}
+	if b.Rule != "" {
+		block.Rule = &domain.Rule{Name: b.Rule}
+	}
+	if err := block.Init(); err != nil {
+		return nil, fmt.Errorf("initialize block attributes: %w", err)
+	}
+	block.SetNewName(b.NewName)
+	return block, nil
+}
+
+// inputFiles returns Jsonnet and Starlark files used to rename blocks.
+func inputFiles(input *domain.Input) []string {
+	files := []string{input.Jsonnet, input.Starlark}
+	for _, rule := range input.Rules {
+		files = append(files, rule.Jsonnet, rule.Starlark)
+	}
+	files = slices.DeleteFunc(files, func(file string) bool {
+		return file == ""
+	})
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// hashFiles returns hashes of files sorted by path.
+// Files which don't exist are ignored, so removed files are detected by comparing hashes.
+func hashFiles(fs afero.Fs, files []string) ([]*FileHash, error) {
+	files = slices.Sorted(slices.Values(files))
+	hashes := make([]*FileHash, 0, len(files))
+	for _, file := range files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", file))
+		}
+		sum := sha256.Sum256(b)
+		hashes = append(hashes, &FileHash{
+			Path:   file,
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	return hashes, nil
+}
@@ -0,0 +1,250 @@
+package planfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/planfile"
+)
+
+func TestPlan_Verify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		modify func(fs afero.Fs) error
+		isErr  bool
+	}{
+		{
+			name:   "not changed",
+			modify: func(afero.Fs) error { return nil },
+		},
+		{
+			name: "changed",
+			modify: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, "main.tf", []byte(`resource "null_resource" "foo_1" {}`), 0o644)
+			},
+			isErr: true,
+		},
+		{
+			name: "added",
+			modify: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, "moved.tf", []byte(""), 0o644)
+			},
+			isErr: true,
+		},
+		{
+			name: "removed",
+			modify: func(fs afero.Fs) error {
+				return fs.Remove("main.tf")
+			},
+			isErr: true,
+		},
+		{
+			name: "changed in a subdirectory",
+			modify: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, "sub/main.tf", []byte(`output "foo" { value = null_resource.foo_1.id }`), 0o644)
+			},
+			isErr: true,
+		},
+		{
+			name: "jsonnet changed",
+			modify: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, "rename.jsonnet", []byte(`"foo_2"`), 0o644)
+			},
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			for path, content := range map[string]string{
+				"main.tf":        `resource "null_resource" "foo-1" {}`,
+				"sub/main.tf":    `output "foo" { value = null_resource.foo-1.id }`,
+				"rename.jsonnet": `"foo_1"`,
+			} {
+				if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			scopeFiles := map[string][]string{
+				".": {"main.tf", "moved.tf", "sub/main.tf"},
+			}
+			block := &domain.Block{
+				File:         "main.tf",
+				BlockType:    "resource",
+				ResourceType: "null_resource",
+				Name:         "foo-1",
+				MovedFile:    "moved.tf",
+			}
+			if err := block.Init(); err != nil {
+				t.Fatal(err)
+			}
+			block.SetNewName("foo_1")
+			p, err := planfile.New(fs, &domain.Input{Jsonnet: "rename.jsonnet"}, map[string]*domain.Dir{
+				".": {
+					Path:   ".",
+					Files:  []string{"main.tf"},
+					Blocks: []*domain.Block{block},
+				},
+			}, nil, scopeFiles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Write(fs, "plan.json"); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.modify(fs); err != nil {
+				t.Fatal(err)
+			}
+			p, err = planfile.Read(fs, "plan.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Verify(fs, scopeFiles); err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			dirs, err := p.ToDirs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dirs["."].Blocks[0].NewTFAddress; got != "null_resource.foo_1" {
+				t.Fatalf("wanted null_resource.foo_1, got %s", got)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		block string
+		isErr bool
+	}{
+		{
+			name:  "valid",
+			block: `{"file": "foo/main.tf", "block_type": "resource", "name": "a", "new_name": "b", "moved_file": "foo/moved.tf"}`,
+		},
+		{
+			name:  "file outside the directory",
+			block: `{"file": "bar/main.tf", "block_type": "resource", "name": "a", "new_name": "b", "moved_file": "foo/moved.tf"}`,
+			isErr: true,
+		},
+		{
+			name:  "moved file outside the directory",
+			block: `{"file": "foo/main.tf", "block_type": "resource", "name": "a", "new_name": "b", "moved_file": "foo/../../moved.tf"}`,
+			isErr: true,
+		},
+		{
+			name:  "moved file in a subdirectory",
+			block: `{"file": "foo/main.tf", "block_type": "resource", "name": "a", "new_name": "b", "moved_file": "foo/bar/moved.tf"}`,
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			content := `{
+  "format_version": 2,
+  "settings": {"emit": ["moved"], "ref_scope": "dir"},
+  "dirs": [{"path": "foo", "files": ["foo/main.tf"], "blocks": [` + tt.block + `]}]
+}`
+			if err := afero.WriteFile(fs, "plan.json", []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := planfile.Read(fs, "plan.json"); err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+		})
+	}
+}
+
+func TestSettings_SetTo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input *domain.Input
+		exp   *domain.Input
+		isErr bool
+	}{
+		{
+			name:  "use settings of the plan",
+			input: &domain.Input{DryRun: true},
+			exp: &domain.Input{
+				DryRun:      true,
+				Emit:        []string{"moved", "state-mv"},
+				StateMvFile: "state_mv.sh",
+				RefScope:    "file",
+			},
+		},
+		{
+			name: "same settings",
+			input: &domain.Input{
+				Emit:     []string{"state-mv", "moved"},
+				RefScope: "file",
+			},
+			exp: &domain.Input{
+				Emit:        []string{"moved", "state-mv"},
+				StateMvFile: "state_mv.sh",
+				RefScope:    "file",
+			},
+		},
+		{
+			name:  "conflicting emit",
+			input: &domain.Input{Emit: []string{"moved"}},
+			isErr: true,
+		},
+		{
+			name:  "conflicting ref scope",
+			input: &domain.Input{RefScope: "dir"},
+			isErr: true,
+		},
+		{
+			name:  "conflicting state mv file",
+			input: &domain.Input{StateMvFile: "tfmv_state_mv.sh"},
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			p, err := planfile.New(fs, &domain.Input{
+				Emit:        []string{"state-mv", "moved"},
+				StateMvFile: "state_mv.sh",
+				RefScope:    "file",
+			}, map[string]*domain.Dir{}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Settings.SetTo(tt.input); err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			if !reflect.DeepEqual(tt.input, tt.exp) {
+				t.Fatalf("wanted %+v, got %+v", tt.exp, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package rename
+
+import "time"
+
+// Timeout exports the timeout of CommandRenamer for tests.
+func (c *CommandRenamer) Timeout() time.Duration {
+	return c.timeout
+}
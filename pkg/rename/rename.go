@@ -9,20 +9,47 @@ import (
 )
 
 // Renamer is an interface to rename a block address.
+// Rename may be called concurrently for different blocks.
 type Renamer interface {
 	Rename(block *domain.Block) (string, error)
 }
 
+// Validator is an optional interface of Renamer.
+// Validate is called after all blocks are renamed.
+type Validator interface {
+	Validate() error
+}
+
+// BatchRenamer is an optional interface of Renamer.
+// If Batch returns true, RenameBlocks is called once with all blocks instead of Rename.
+// The i-th new name must correspond to the i-th block.
+type BatchRenamer interface {
+	Batch() bool
+	RenameBlocks(blocks []*domain.Block) ([]string, error)
+}
+
 // New creates a Renamer.
 func New(logger *slog.Logger, fs afero.Fs, input *domain.Input) (Renamer, error) {
+	if len(input.Changes) != 0 {
+		return NewChangesRenamer(input.Changes)
+	}
+	if len(input.Rules) != 0 {
+		return NewRulesRenamer(logger, fs, input.Rules)
+	}
 	if input.Replace != "" {
 		return NewReplaceRenamer(input.Replace)
 	}
 	if input.Jsonnet != "" {
 		return NewJsonnetRenamer(logger, fs, input.Jsonnet)
 	}
+	if input.Starlark != "" {
+		return NewStarlarkRenamer(logger, fs, input.Starlark)
+	}
 	if input.Regexp != "" {
 		return NewRegexpRenamer(input.Regexp)
 	}
-	return nil, errors.New("one of --jsonnet or --replace or --regexp must be specified")
+	if input.Command != "" {
+		return NewCommandRenamer(input.Command, input.CommandTimeout, input.CommandBatch)
+	}
+	return nil, errors.New("one of --jsonnet or --starlark or --replace or --regexp or --command or --changes or rules must be specified")
 }
@@ -0,0 +1,90 @@
+package rename
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// ChangesRenamer is a Renamer which renames blocks according to a given list of changes.
+type ChangesRenamer struct {
+	changes map[changeKey]*domain.Change
+	matched map[changeKey]struct{}
+	// mutex guards matched because Rename is called concurrently.
+	mutex sync.Mutex
+}
+
+type changeKey struct {
+	dir     string
+	address string
+}
+
+// NewChangesRenamer creates a ChangesRenamer.
+func NewChangesRenamer(changes []*domain.Change) (*ChangesRenamer, error) {
+	m := make(map[changeKey]*domain.Change, len(changes))
+	for _, change := range changes {
+		key := changeKey{
+			dir:     filepath.Clean(change.Dir),
+			address: change.Address,
+		}
+		if _, ok := m[key]; ok {
+			return nil, slogerr.With(errors.New("a change is duplicated"), "dir", change.Dir, "address", change.Address) //nolint:wrapcheck
+		}
+		m[key] = change
+	}
+	return &ChangesRenamer{
+		changes: m,
+		matched: make(map[changeKey]struct{}, len(changes)),
+	}, nil
+}
+
+// Rename renames a block address.
+func (r *ChangesRenamer) Rename(block *domain.Block) (string, error) {
+	key := changeKey{
+		dir:     filepath.Dir(block.File),
+		address: block.TFAddress,
+	}
+	change, ok := r.changes[key]
+	if !ok {
+		return "", nil
+	}
+	r.mutex.Lock()
+	r.matched[key] = struct{}{}
+	r.mutex.Unlock()
+	prefix := strings.TrimSuffix(block.TFAddress, block.Name)
+	newName, ok := strings.CutPrefix(change.NewAddress, prefix)
+	if !ok || strings.Contains(newName, ".") {
+		return "", slogerr.With(errors.New("the new address must have the same block type and resource type as the address"), "address", change.Address, "new_address", change.NewAddress) //nolint:wrapcheck
+	}
+	return newName, nil
+}
+
+// Validate checks if every change matches a block.
+// It returns errors of all unmatched changes sorted by the directory and the address.
+func (r *ChangesRenamer) Validate() error {
+	keys := slices.SortedFunc(maps.Keys(r.changes), func(a, b changeKey) int {
+		return cmp.Or(cmp.Compare(a.dir, b.dir), cmp.Compare(a.address, b.address))
+	})
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	errs := []error{}
+	for _, key := range keys {
+		if _, ok := r.matched[key]; ok {
+			continue
+		}
+		change := r.changes[key]
+		errs = append(errs, fmt.Errorf("the address %s isn't found in the directory %s", change.Address, change.Dir))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return slogerr.With(errors.Join(errs...), "num_of_unmatched_changes", len(errs)) //nolint:wrapcheck
+}
@@ -0,0 +1,73 @@
+package rename_test
+
+import (
+	"testing"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/rename"
+)
+
+func TestChangesRenamer_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		changes []*domain.Change
+		blocks  []*domain.Block
+		exp     string
+	}{
+		{
+			name: "all changes match",
+			changes: []*domain.Change{
+				{Dir: "foo", Address: "null_resource.a", NewAddress: "null_resource.b"},
+			},
+			blocks: []*domain.Block{
+				{File: "foo/main.tf", BlockType: "resource", ResourceType: "null_resource", Name: "a"},
+			},
+		},
+		{
+			name: "all unmatched changes are reported in order",
+			changes: []*domain.Change{
+				{Dir: "foo", Address: "null_resource.z", NewAddress: "null_resource.y"},
+				{Dir: "bar", Address: "null_resource.c", NewAddress: "null_resource.d"},
+				{Dir: "foo", Address: "null_resource.a", NewAddress: "null_resource.b"},
+				{Dir: "foo", Address: "null_resource.m", NewAddress: "null_resource.n"},
+			},
+			blocks: []*domain.Block{
+				{File: "foo/main.tf", BlockType: "resource", ResourceType: "null_resource", Name: "m"},
+			},
+			exp: "the address null_resource.c isn't found in the directory bar\n" +
+				"the address null_resource.a isn't found in the directory foo\n" +
+				"the address null_resource.z isn't found in the directory foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			renamer, err := rename.NewChangesRenamer(tt.changes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, block := range tt.blocks {
+				if err := block.Init(); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := renamer.Rename(block); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = renamer.Validate()
+			if tt.exp == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("error is expected")
+			}
+			if err.Error() != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, err.Error())
+			}
+		})
+	}
+}
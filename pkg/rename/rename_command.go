@@ -0,0 +1,136 @@
+package rename
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// CommandRenamer renames blocks by an external command.
+// A block is passed to the command's stdin as JSON, and the command outputs a new name as a JSON string.
+// In batch mode, all blocks are passed at once as JSON Lines,
+// and the command outputs new names as JSON Lines in the same order.
+type CommandRenamer struct {
+	args    []string
+	timeout time.Duration
+	batch   bool
+}
+
+// NewCommandRenamer creates a CommandRenamer.
+// command is split into arguments like a shell, but it isn't run via a shell.
+// If timeout is zero, domain.DefaultCommandTimeout is used.
+// If timeout is negative, the command never times out.
+func NewCommandRenamer(command string, timeout time.Duration, batch bool) (*CommandRenamer, error) {
+	args, err := shellwords.Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("parse a command: %w", slogerr.With(err, "command", command))
+	}
+	if len(args) == 0 {
+		return nil, errors.New("the command is empty")
+	}
+	if timeout == 0 {
+		timeout = domain.DefaultCommandTimeout
+	}
+	return &CommandRenamer{
+		args:    args,
+		timeout: timeout,
+		batch:   batch,
+	}, nil
+}
+
+// Batch returns true if blocks are passed to the command at once.
+func (c *CommandRenamer) Batch() bool {
+	return c.batch
+}
+
+// Rename runs the command per block and returns a new name.
+func (c *CommandRenamer) Rename(block *domain.Block) (string, error) {
+	b, err := json.Marshal(block)
+	if err != nil {
+		return "", fmt.Errorf("marshal a block: %w", err)
+	}
+	out, err := c.run(append(b, '\n'))
+	if err != nil {
+		return "", err
+	}
+	var dest string
+	if err := json.Unmarshal(out, &dest); err != nil {
+		return "", fmt.Errorf("unmarshal the command output as a JSON string: %w", slogerr.With(err, "output", string(out)))
+	}
+	return dest, nil
+}
+
+// RenameBlocks runs the command once and returns new names of blocks.
+// The i-th new name corresponds to the i-th block.
+func (c *CommandRenamer) RenameBlocks(blocks []*domain.Block) ([]string, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, block := range blocks {
+		if err := encoder.Encode(block); err != nil {
+			return nil, fmt.Errorf("marshal a block: %w", slogerr.With(err, "file", block.File, "name", block.Name))
+		}
+	}
+	out, err := c.run(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(blocks))
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024) //nolint:mnd
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var dest string
+		if err := json.Unmarshal([]byte(line), &dest); err != nil {
+			return nil, fmt.Errorf("unmarshal the command output as a JSON string: %w", slogerr.With(err, "line", len(names)+1))
+		}
+		names = append(names, dest)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read the command output: %w", err)
+	}
+	if len(names) != len(blocks) {
+		return nil, slogerr.With(errors.New("the number of new names doesn't match the number of blocks"), //nolint:wrapcheck
+			"num_of_blocks", len(blocks), "num_of_new_names", len(names))
+	}
+	return names, nil
+}
+
+// run runs the command with stdin and returns the stdout.
+// If the command exits with non-zero or times out, run returns an error including the stderr.
+func (c *CommandRenamer) run(stdin []byte) ([]byte, error) {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(stdin)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("run a command: %w", slogerr.With(ctx.Err(), "command", c.args[0], "timeout", c.timeout.String(), "stderr", stderr.String()))
+		}
+		return nil, fmt.Errorf("run a command: %w", slogerr.With(err, "command", c.args[0], "stderr", stderr.String()))
+	}
+	return bytes.TrimSpace(stdout.Bytes()), nil
+}
@@ -0,0 +1,215 @@
+package rename_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/rename"
+)
+
+// errorLog returns a log of err including attributes of slogerr.
+func errorLog(err error) string {
+	buf := &bytes.Buffer{}
+	slogerr.WithError(slog.New(slog.NewTextHandler(buf, nil)), err).Error("failed")
+	return buf.String()
+}
+
+func newBlocks(t *testing.T, names ...string) []*domain.Block {
+	t.Helper()
+	blocks := make([]*domain.Block, len(names))
+	for i, name := range names {
+		block := &domain.Block{
+			File:         "main.tf",
+			BlockType:    "resource",
+			ResourceType: "null_resource",
+			Name:         name,
+		}
+		if err := block.Init(); err != nil {
+			t.Fatal(err)
+		}
+		blocks[i] = block
+	}
+	return blocks
+}
+
+func TestNewCommandRenamer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		exp     time.Duration
+		isErr   bool
+	}{
+		{
+			name:    "default timeout",
+			command: "cat",
+			exp:     domain.DefaultCommandTimeout,
+		},
+		{
+			name:    "timeout",
+			command: "cat",
+			timeout: time.Second,
+			exp:     time.Second,
+		},
+		{
+			name:    "no timeout",
+			command: "cat",
+			timeout: -1,
+			exp:     -1,
+		},
+		{
+			name:  "empty command",
+			isErr: true,
+		},
+		{
+			name:    "invalid command",
+			command: `sh -c "echo`,
+			isErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			renamer, err := rename.NewCommandRenamer(tt.command, tt.timeout, false)
+			if err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			if renamer.Timeout() != tt.exp {
+				t.Fatalf("wanted %s, got %s", tt.exp, renamer.Timeout())
+			}
+		})
+	}
+}
+
+func TestCommandRenamer_Rename(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		exp     string
+		// expErr is a part of an expected error log.
+		expErr string
+	}{
+		{
+			name:    "rename",
+			command: `sh -c "sed -E 's/.*\"name\":\"([^\"]*)\".*/\"\\1_new\"/'"`,
+			exp:     "foo_new",
+		},
+		{
+			name:    "non-JSON stdout",
+			command: "echo foo_new",
+			expErr:  "unmarshal the command output as a JSON string",
+		},
+		{
+			name:    "stderr",
+			command: `sh -c "echo invalid block >&2; exit 1"`,
+			expErr:  `stderr="invalid block\n"`,
+		},
+		{
+			name:    "timeout",
+			command: "sleep 10",
+			timeout: 100 * time.Millisecond,
+			expErr:  "context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			renamer, err := rename.NewCommandRenamer(tt.command, tt.timeout, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name, err := renamer.Rename(newBlocks(t, "foo")[0])
+			if err != nil {
+				if tt.expErr == "" {
+					t.Fatal(err)
+				}
+				if log := errorLog(err); !strings.Contains(log, tt.expErr) {
+					t.Fatalf("the error log must include %q: %s", tt.expErr, log)
+				}
+				return
+			}
+			if tt.expErr != "" {
+				t.Fatal("error is expected")
+			}
+			if name != tt.exp {
+				t.Fatalf("wanted %q, got %q", tt.exp, name)
+			}
+		})
+	}
+}
+
+func TestCommandRenamer_RenameBlocks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		command string
+		exp     []string
+		// expErr is a part of an expected error log.
+		expErr string
+	}{
+		{
+			name:    "rename",
+			command: `sh -c "sed -E 's/.*\"name\":\"([^\"]*)\".*/\"\\1_new\"/'"`,
+			exp:     []string{"foo_new", "bar_new"},
+		},
+		{
+			name:    "blank lines are ignored",
+			command: `sh -c "printf '\"foo_new\"\n\n\"bar_new\"\n'"`,
+			exp:     []string{"foo_new", "bar_new"},
+		},
+		{
+			name:    "count mismatch",
+			command: `echo '"foo_new"'`,
+			expErr:  "num_of_blocks=2 num_of_new_names=1",
+		},
+		{
+			name:    "non-JSON stdout",
+			command: `sh -c "printf '\"foo_new\"\nbar_new\n'"`,
+			expErr:  "line=2",
+		},
+		{
+			name:    "stderr",
+			command: `sh -c "echo invalid blocks >&2; exit 1"`,
+			expErr:  `stderr="invalid blocks\n"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			renamer, err := rename.NewCommandRenamer(tt.command, 0, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names, err := renamer.RenameBlocks(newBlocks(t, "foo", "bar"))
+			if err != nil {
+				if tt.expErr == "" {
+					t.Fatal(err)
+				}
+				if log := errorLog(err); !strings.Contains(log, tt.expErr) {
+					t.Fatalf("the error log must include %q: %s", tt.expErr, log)
+				}
+				return
+			}
+			if tt.expErr != "" {
+				t.Fatal("error is expected")
+			}
+			if strings.Join(names, ",") != strings.Join(tt.exp, ",") {
+				t.Fatalf("wanted %v, got %v", tt.exp, names)
+			}
+		})
+	}
+}
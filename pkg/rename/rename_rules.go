@@ -0,0 +1,136 @@
+package rename
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// RulesRenamer renames blocks by an ordered list of rules.
+// The first rule whose scope matches a block renames the block, and the rule is recorded to the block.
+// Blocks matching no rule aren't renamed.
+type RulesRenamer struct {
+	rules    []*domain.Rule
+	renamers []Renamer
+}
+
+func NewRulesRenamer(logger *slog.Logger, fs afero.Fs, rules []*domain.Rule) (*RulesRenamer, error) {
+	renamers := make([]Renamer, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("validate a rule: %w", err)
+		}
+		renamer, err := newRuleRenamer(logger, fs, rule)
+		if err != nil {
+			return nil, fmt.Errorf("initialize a renamer of a rule: %w", slogerr.With(err, "rule", rule.Name))
+		}
+		renamers[i] = renamer
+	}
+	return &RulesRenamer{
+		rules:    rules,
+		renamers: renamers,
+	}, nil
+}
+
+func newRuleRenamer(logger *slog.Logger, fs afero.Fs, rule *domain.Rule) (Renamer, error) {
+	switch {
+	case rule.Replace != "":
+		return NewReplaceRenamer(rule.Replace)
+	case rule.Regexp != "":
+		return NewRegexpRenamer(rule.Regexp)
+	case rule.Jsonnet != "":
+		return NewJsonnetRenamer(logger, fs, rule.Jsonnet)
+	case rule.Starlark != "":
+		return NewStarlarkRenamer(logger, fs, rule.Starlark)
+	default:
+		return NewCommandRenamer(rule.Command, rule.CommandTimeout, rule.CommandBatch)
+	}
+}
+
+// match returns the index of the first rule matching the block.
+// If no rule matches the block, match returns -1.
+func (r *RulesRenamer) match(block *domain.Block) int {
+	for i, rule := range r.rules {
+		if rule.Match(block) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *RulesRenamer) Rename(block *domain.Block) (string, error) {
+	i := r.match(block)
+	if i < 0 {
+		return "", nil
+	}
+	block.Rule = r.rules[i]
+	newName, err := r.renamers[i].Rename(block)
+	if err != nil {
+		return "", slogerr.With(err, "rule", r.rules[i].Name) //nolint:wrapcheck
+	}
+	return newName, nil
+}
+
+// Batch returns true if any rule's renamer works in batch mode.
+func (r *RulesRenamer) Batch() bool {
+	for _, renamer := range r.renamers {
+		if br, ok := renamer.(BatchRenamer); ok && br.Batch() {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameBlocks groups blocks by rules and renames each group.
+// Groups of renamers in batch mode are renamed at once.
+func (r *RulesRenamer) RenameBlocks(blocks []*domain.Block) ([]string, error) {
+	newNames := make([]string, len(blocks))
+	groups := make([][]int, len(r.rules))
+	for i, block := range blocks {
+		j := r.match(block)
+		if j < 0 {
+			continue
+		}
+		block.Rule = r.rules[j]
+		groups[j] = append(groups[j], i)
+	}
+	for j, indices := range groups {
+		if len(indices) == 0 {
+			continue
+		}
+		if err := r.renameGroup(j, blocks, indices, newNames); err != nil {
+			return nil, slogerr.With(err, "rule", r.rules[j].Name) //nolint:wrapcheck
+		}
+	}
+	return newNames, nil
+}
+
+// renameGroup renames blocks matching the j-th rule and stores new names to newNames.
+func (r *RulesRenamer) renameGroup(j int, blocks []*domain.Block, indices []int, newNames []string) error {
+	renamer := r.renamers[j]
+	if br, ok := renamer.(BatchRenamer); ok && br.Batch() {
+		group := make([]*domain.Block, len(indices))
+		for k, i := range indices {
+			group[k] = blocks[i]
+		}
+		names, err := br.RenameBlocks(group)
+		if err != nil {
+			return fmt.Errorf("get new names: %w", err)
+		}
+		for k, i := range indices {
+			newNames[i] = names[k]
+		}
+		return nil
+	}
+	for _, i := range indices {
+		newName, err := renamer.Rename(blocks[i])
+		if err != nil {
+			return fmt.Errorf("get a new name: %w", slogerr.With(err, "file", blocks[i].File, "address", blocks[i].TFAddress))
+		}
+		newNames[i] = newName
+	}
+	return nil
+}
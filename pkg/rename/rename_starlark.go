@@ -0,0 +1,101 @@
+package rename
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// starlarkMaxSteps is the maximum number of execution steps of each Starlark call.
+// It prevents infinite loops.
+const starlarkMaxSteps = 1_000_000
+
+// StarlarkRenamer renames blocks by a Starlark function `rename(block)`.
+// Starlark scripts can't access the file system, the network, and the clock, and load statements aren't allowed,
+// so the result depends on only the script and the block.
+type StarlarkRenamer struct {
+	logger *slog.Logger
+	file   string
+	rename *starlark.Function
+}
+
+func NewStarlarkRenamer(logger *slog.Logger, fs afero.Fs, file string) (*StarlarkRenamer, error) {
+	logger.Debug("reading a starlark file")
+	b, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("read a starlark file: %w", err)
+	}
+	logger.Debug("executing a starlark file")
+	thread := newStarlarkThread(logger, file)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, file, b, StarlarkPredeclared())
+	if err != nil {
+		return nil, fmt.Errorf("execute a starlark file: %w", starlarkError(err))
+	}
+	fn, ok := globals["rename"].(*starlark.Function)
+	if !ok {
+		return nil, errors.New("a starlark file must define a function rename(block)")
+	}
+	if fn.NumParams() != 1 {
+		return nil, slogerr.With(errors.New("the function rename must take one parameter"), "num_of_params", fn.NumParams()) //nolint:wrapcheck
+	}
+	return &StarlarkRenamer{
+		logger: logger,
+		file:   file,
+		rename: fn,
+	}, nil
+}
+
+func (s *StarlarkRenamer) Rename(block *domain.Block) (string, error) {
+	arg := starlarkstruct.FromStringDict(starlark.String("block"), starlark.StringDict{
+		"file":          starlark.String(block.File),
+		"block_type":    starlark.String(block.BlockType),
+		"resource_type": starlark.String(block.ResourceType),
+		"name":          starlark.String(block.Name),
+	})
+	thread := newStarlarkThread(s.logger, s.file)
+	v, err := starlark.Call(thread, s.rename, starlark.Tuple{arg}, nil)
+	if err != nil {
+		return "", fmt.Errorf("call the starlark function rename: %w", starlarkError(err))
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return "", nil
+	case starlark.String:
+		return string(v), nil
+	default:
+		return "", slogerr.With(errors.New("the function rename must return a string or None"), "type", v.Type()) //nolint:wrapcheck
+	}
+}
+
+// newStarlarkThread creates a Starlark thread.
+// A thread is created per call so that the renamer can be used concurrently.
+// Outputs of print are logged.
+func newStarlarkThread(logger *slog.Logger, file string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: file,
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, slogerr.With(errors.New("load statements aren't allowed"), "module", module) //nolint:wrapcheck
+		},
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.Info("print in starlark", "file", file, "message", msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(starlarkMaxSteps)
+	return thread
+}
+
+// starlarkError adds a backtrace to a Starlark evaluation error.
+func starlarkError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return slogerr.With(err, "backtrace", evalErr.Backtrace()) //nolint:wrapcheck
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+package rename
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// StarlarkPredeclared returns predeclared modules of Starlark.
+// The modules mirror native functions of Jsonnet registered by SetNativeFunctions.
+// Unlike Jsonnet, functions return values directly instead of [value, error],
+// and errors stop the execution.
+func StarlarkPredeclared() starlark.StringDict {
+	return starlark.StringDict{
+		"filepath": newStarlarkModule("filepath", map[string]starlarkFunc{
+			"Base": strFunc("path", filepath.Base),
+		}),
+		"path": newStarlarkModule("path", map[string]starlarkFunc{
+			"Base":  strFunc("path", path.Base),
+			"Clean": strFunc("path", path.Clean),
+			"Dir":   strFunc("path", path.Dir),
+			"Ext":   strFunc("path", path.Ext),
+			"IsAbs": starlarkPathIsAbs,
+			"Match": starlarkPathMatch,
+			"Split": starlarkPathSplit,
+		}),
+		"regexp": newStarlarkModule("regexp", map[string]starlarkFunc{
+			"MatchString": starlarkRegexpMatchString,
+		}),
+		"strings": newStarlarkModule("strings", map[string]starlarkFunc{
+			"Contains":     strBoolFunc("substr", strings.Contains),
+			"ContainsAny":  strBoolFunc("chars", strings.ContainsAny),
+			"Count":        strIntFunc("substr", strings.Count),
+			"Cut":          starlarkStringsCut,
+			"CutPrefix":    strCutFunc("prefix", strings.CutPrefix),
+			"CutSuffix":    strCutFunc("suffix", strings.CutSuffix),
+			"EqualFold":    strBoolFunc("t", strings.EqualFold),
+			"Fields":       starlarkStringsFields,
+			"LastIndex":    strIntFunc("substr", strings.LastIndex),
+			"LastIndexAny": strIntFunc("chars", strings.LastIndexAny),
+			"Repeat":       starlarkStringsRepeat,
+			"Replace":      starlarkStringsReplace,
+			"TrimPrefix":   starlarkStringsTrimPrefix,
+		}),
+		"url": newStarlarkModule("url", map[string]starlarkFunc{
+			"Parse": starlarkURLParse,
+		}),
+	}
+}
+
+type starlarkFunc func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)
+
+func newStarlarkModule(name string, funcs map[string]starlarkFunc) *starlarkstruct.Module {
+	members := make(starlark.StringDict, len(funcs))
+	for k, f := range funcs {
+		members[k] = starlark.NewBuiltin(name+"."+k, f)
+	}
+	return &starlarkstruct.Module{
+		Name:    name,
+		Members: members,
+	}
+}
+
+// strFunc converts func(string) string to a Starlark function.
+func strFunc(param string, f func(string) string) starlarkFunc {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, param, &s); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return starlark.String(f(s)), nil
+	}
+}
+
+// strBoolFunc converts func(string, string) bool to a Starlark function.
+func strBoolFunc(param string, f func(string, string) bool) starlarkFunc {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s, t string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, param, &t); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return starlark.Bool(f(s, t)), nil
+	}
+}
+
+// strIntFunc converts func(string, string) int to a Starlark function.
+func strIntFunc(param string, f func(string, string) int) starlarkFunc {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s, t string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, param, &t); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return starlark.MakeInt(f(s, t)), nil
+	}
+}
+
+// strCutFunc converts strings.CutPrefix and strings.CutSuffix to Starlark functions.
+// They return a tuple (after, found).
+func strCutFunc(param string, f func(string, string) (string, bool)) starlarkFunc {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s, t string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, param, &t); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		after, found := f(s, t)
+		return starlark.Tuple{starlark.String(after), starlark.Bool(found)}, nil
+	}
+}
+
+func starlarkPathIsAbs(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return starlark.Bool(path.IsAbs(p)), nil
+}
+
+func starlarkPathMatch(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, name string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "pattern", &pattern, "name", &name); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	matched, err := path.Match(pattern, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return starlark.Bool(matched), nil
+}
+
+func starlarkPathSplit(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	dir, file := path.Split(p)
+	return starlark.Tuple{starlark.String(dir), starlark.String(file)}, nil
+}
+
+func starlarkRegexpMatchString(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "pattern", &pattern, "s", &s); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	matched, err := regexp.MatchString(pattern, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return starlark.Bool(matched), nil
+}
+
+func starlarkStringsCut(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s, sep string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, "sep", &sep); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	before, after, found := strings.Cut(s, sep)
+	return starlark.Tuple{starlark.String(before), starlark.String(after), starlark.Bool(found)}, nil
+}
+
+func starlarkStringsFields(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	fields := strings.Fields(s)
+	list := make([]starlark.Value, len(fields))
+	for i, field := range fields {
+		list[i] = starlark.String(field)
+	}
+	return starlark.NewList(list), nil
+}
+
+// maxRepeatLength is the maximum length of a string created by strings.Repeat.
+// It prevents scripts from exhausting memory.
+const maxRepeatLength = 1024 * 1024
+
+func starlarkStringsRepeat(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s string
+	var count int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, "count", &count); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("%s: negative count", fn.Name())
+	}
+	if count != 0 && len(s) > maxRepeatLength/count {
+		return nil, fmt.Errorf("%s: the result is too long", fn.Name())
+	}
+	return starlark.String(strings.Repeat(s, count)), nil
+}
+
+func starlarkStringsReplace(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s, old, replacement string
+	var n int
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, "old", &old, "new", &replacement, "n", &n); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return starlark.String(strings.Replace(s, old, replacement, n)), nil
+}
+
+func starlarkStringsTrimPrefix(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s, prefix string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s, "prefix", &prefix); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return starlark.String(strings.TrimPrefix(s, prefix)), nil
+}
+
+// starlarkURLParse parses a URL and returns a struct.
+// Fields are same as url.Parse of Jsonnet.
+func starlarkURLParse(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var rawURL string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "rawURL", &rawURL); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	q := u.Query()
+	query := starlark.NewDict(len(q))
+	// keys are sorted so that the iteration order of the dict is deterministic.
+	for _, k := range slices.Sorted(maps.Keys(q)) {
+		v := q[k]
+		list := make([]starlark.Value, len(v))
+		for i, s := range v {
+			list[i] = starlark.String(s)
+		}
+		if err := query.SetKey(starlark.String(k), starlark.NewList(list)); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+	}
+	return starlarkstruct.FromStringDict(starlark.String("url"), starlark.StringDict{
+		"Scheme":      starlark.String(u.Scheme),
+		"Opaque":      starlark.String(u.Opaque),
+		"Host":        starlark.String(u.Host),
+		"Path":        starlark.String(u.Path),
+		"RawPath":     starlark.String(u.RawPath),
+		"OmitHost":    starlark.Bool(u.OmitHost),
+		"ForceQuery":  starlark.Bool(u.ForceQuery),
+		"RawQuery":    starlark.String(u.RawQuery),
+		"Fragment":    starlark.String(u.Fragment),
+		"RawFragment": starlark.String(u.RawFragment),
+		"Query":       query,
+	}), nil
+}
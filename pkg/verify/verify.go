@@ -0,0 +1,325 @@
+package verify
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// Verifier verifies the Terraform configuration rewritten by tfmv.
+type Verifier struct {
+	fs afero.Fs
+}
+
+// New creates a Verifier.
+func New(fs afero.Fs) *Verifier {
+	return &Verifier{
+		fs: fs,
+	}
+}
+
+// Issue represents a problem found in the rewritten configuration.
+type Issue struct {
+	// File is a file path.
+	File string
+	// Line is a line number starting from 1.
+	Line int
+	// Column is a column number starting from 1.
+	Column int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a string like "main.tf:3:5: message".
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.File, i.Line, i.Column, i.Message)
+}
+
+// Verify re-parses every directory in dirs and returns issues.
+// It checks if
+//
+//   - every file is a valid HCL file
+//   - no reference to any old address remains outside moved blocks' from fields
+//   - every moved block points at an existing block
+func (v *Verifier) Verify(logger *slog.Logger, dirs map[string]*domain.Dir) ([]*Issue, error) {
+	paths := make([]string, 0, len(dirs))
+	for p := range dirs {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	issues := []*Issue{}
+	for _, p := range paths {
+		dir := dirs[p]
+		logger := logger.With("dir", dir.Path)
+		logger.Debug("verifying a directory")
+		arr, err := v.verifyDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("verify a directory: %w", slogerr.With(err, "dir", dir.Path))
+		}
+		issues = append(issues, arr...)
+	}
+	return issues, nil
+}
+
+// file is a parsed Terraform configuration file.
+type file struct {
+	path   string
+	src    []byte
+	body   *hclsyntax.Body
+	moveds []*moved
+}
+
+// moved is a moved block.
+type moved struct {
+	from      string
+	to        string
+	fromRange hcl.Range
+	toRange   hcl.Range
+}
+
+func (v *Verifier) verifyDir(dir *domain.Dir) ([]*Issue, error) {
+	paths, err := afero.Glob(v.fs, filepath.Join(dir.Path, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("find files: %w", err)
+	}
+	issues := []*Issue{}
+	files := make([]*file, 0, len(paths))
+	for _, p := range paths {
+		b, err := afero.ReadFile(v.fs, p)
+		if err != nil {
+			return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", p))
+		}
+		f, diags := parseFile(b, p)
+		for _, diag := range diags {
+			if diag.Severity != hcl.DiagError {
+				continue
+			}
+			issues = append(issues, diagIssue(p, diag))
+		}
+		if f == nil {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	// addresses of declared resources, data sources, and modules
+	declared := map[string]hcl.Range{}
+	// from addresses of moved blocks
+	froms := map[string]struct{}{}
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			if addr := declaredAddress(block); addr != "" {
+				declared[addr] = block.DefRange()
+			}
+		}
+		for _, m := range f.moveds {
+			froms[m.from] = struct{}{}
+		}
+	}
+
+	newAddrs := make(map[string]struct{}, len(dir.Blocks))
+	for _, block := range dir.Blocks {
+		newAddrs[block.NewTFAddress] = struct{}{}
+	}
+
+	for _, block := range dir.Blocks {
+		if _, ok := newAddrs[block.TFAddress]; ok {
+			// The old address is reused by another renamed block.
+			continue
+		}
+		if rng, ok := declared[block.TFAddress]; ok {
+			issues = append(issues, &Issue{
+				File:    rng.Filename,
+				Line:    rng.Start.Line,
+				Column:  rng.Start.Column,
+				Message: fmt.Sprintf("the block %s still exists", block.TFAddress),
+			})
+		}
+		for _, f := range files {
+			issues = append(issues, findReferences(f, block)...)
+		}
+	}
+
+	for _, f := range files {
+		for _, m := range f.moveds {
+			if _, ok := declared[m.to]; ok {
+				continue
+			}
+			if _, ok := froms[m.to]; ok {
+				// moved blocks are chained
+				continue
+			}
+			issues = append(issues, &Issue{
+				File:    f.path,
+				Line:    m.toRange.Start.Line,
+				Column:  m.toRange.Start.Column,
+				Message: fmt.Sprintf("the moved block's to address %s doesn't point at an existing block", m.to),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// findReferences returns issues of references to the block's old address in a file.
+// References in moved blocks' from fields are ignored.
+func findReferences(f *file, block *domain.Block) []*Issue {
+	issues := []*Issue{}
+	for _, idx := range block.FindRefs(f.src) {
+		if f.inMovedFrom(idx[0]) {
+			continue
+		}
+		line, column := position(f.src, idx[0])
+		issues = append(issues, &Issue{
+			File:    f.path,
+			Line:    line,
+			Column:  column,
+			Message: fmt.Sprintf("a reference to the old address %s remains", block.TFAddress),
+		})
+	}
+	return issues
+}
+
+func (f *file) inMovedFrom(offset int) bool {
+	for _, m := range f.moveds {
+		if offset >= m.fromRange.Start.Byte && offset < m.fromRange.End.Byte {
+			return true
+		}
+	}
+	return false
+}
+
+// position converts a byte offset to a line number and a column number.
+func position(src []byte, offset int) (int, int) {
+	line, column := 1, 1
+	for _, c := range src[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return line, column
+}
+
+func diagIssue(filePath string, diag *hcl.Diagnostic) *Issue {
+	issue := &Issue{
+		File:    filePath,
+		Line:    1,
+		Column:  1,
+		Message: "invalid HCL: " + diag.Summary,
+	}
+	if diag.Detail != "" {
+		issue.Message += ": " + diag.Detail
+	}
+	if diag.Subject != nil {
+		issue.Line = diag.Subject.Start.Line
+		issue.Column = diag.Subject.Start.Column
+	}
+	return issue
+}
+
+func parseFile(src []byte, filePath string) (*file, hcl.Diagnostics) {
+	f, diags := hclsyntax.ParseConfig(src, filePath, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, diags
+	}
+	ret := &file{
+		path: filePath,
+		src:  src,
+		body: body,
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "moved" {
+			continue
+		}
+		m, moreDiags := parseMoved(block)
+		diags = append(diags, moreDiags...)
+		if m == nil {
+			continue
+		}
+		ret.moveds = append(ret.moveds, m)
+	}
+	return ret, diags
+}
+
+func parseMoved(block *hclsyntax.Block) (*moved, hcl.Diagnostics) {
+	from, ok := block.Body.Attributes["from"]
+	if !ok {
+		return nil, nil
+	}
+	to, ok := block.Body.Attributes["to"]
+	if !ok {
+		return nil, nil
+	}
+	fromTraversal, diags := hcl.AbsTraversalForExpr(from.Expr)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	toTraversal, diags := hcl.AbsTraversalForExpr(to.Expr)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return &moved{
+		from:      blockAddress(fromTraversal),
+		to:        blockAddress(toTraversal),
+		fromRange: from.Expr.Range(),
+		toRange:   to.Expr.Range(),
+	}, nil
+}
+
+// blockAddress converts a traversal to an address of the resource or module block.
+// Instance keys are removed.
+// If a traversal points at an object in a module, the module address is returned.
+func blockAddress(traversal hcl.Traversal) string {
+	names := make([]string, 0, len(traversal))
+	for _, t := range traversal {
+		switch s := t.(type) {
+		case hcl.TraverseRoot:
+			names = append(names, s.Name)
+		case hcl.TraverseAttr:
+			names = append(names, s.Name)
+		}
+		if len(names) == 2 { //nolint:mnd
+			break
+		}
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return names[0] + "." + names[1]
+}
+
+// declaredAddress returns a Terraform address of a resource, data, or module block.
+// If the block is none of them, an empty string is returned.
+func declaredAddress(block *hclsyntax.Block) string {
+	switch block.Type {
+	case "resource":
+		if len(block.Labels) != 2 { //nolint:mnd
+			return ""
+		}
+		return block.Labels[0] + "." + block.Labels[1]
+	case "data":
+		if len(block.Labels) != 2 { //nolint:mnd
+			return ""
+		}
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	case "module":
+		if len(block.Labels) != 1 {
+			return ""
+		}
+		return "module." + block.Labels[0]
+	}
+	return ""
+}
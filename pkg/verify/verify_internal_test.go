@@ -0,0 +1,116 @@
+package verify_test
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/verify"
+)
+
+func TestVerifier_Verify(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	tests := []struct {
+		name   string
+		files  map[string]string
+		blocks []*domain.Block
+		// issues is a list of prefixes of expected issues.
+		issues []string
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+output "id" {
+  value = null_resource.foo_1.id
+}
+`,
+				"moved.tf": `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			},
+			blocks: []*domain.Block{
+				{
+					File:         "main.tf",
+					BlockType:    "resource",
+					ResourceType: "null_resource",
+					Name:         "foo-1",
+					NewName:      "foo_1",
+				},
+			},
+			issues: []string{},
+		},
+		{
+			name: "invalid",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+output "id" {
+  value = null_resource.foo-1.id
+}
+`,
+				"moved.tf": `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_2
+}
+`,
+				"broken.tf": `resource "null_resource" {
+`,
+			},
+			blocks: []*domain.Block{
+				{
+					File:         "main.tf",
+					BlockType:    "resource",
+					ResourceType: "null_resource",
+					Name:         "foo-1",
+					NewName:      "foo_1",
+				},
+			},
+			issues: []string{
+				"broken.tf:1:26: invalid HCL: Unclosed configuration block",
+				"main.tf:4:11: a reference to the old address null_resource.foo-1 remains",
+				"moved.tf:3:10: the moved block's to address null_resource.foo_2 doesn't point at an existing block",
+			},
+		},
+	}
+	logger := slog.New(slog.DiscardHandler)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, block := range tt.blocks {
+				if err := block.Init(); err != nil {
+					t.Fatal(err)
+				}
+				block.SetNewName(block.NewName)
+			}
+			dir := &domain.Dir{
+				Path:   filepath.Dir("main.tf"),
+				Blocks: tt.blocks,
+			}
+			issues, err := verify.New(fs).Verify(logger, map[string]*domain.Dir{dir.Path: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != len(tt.issues) {
+				t.Fatalf("wanted %d issues, got %d: %v", len(tt.issues), len(issues), issues)
+			}
+			for i, issue := range issues {
+				if !strings.HasPrefix(issue.String(), tt.issues[i]) {
+					t.Errorf("issues[%d]: wanted the prefix %q, got %q", i, tt.issues[i], issue.String())
+				}
+			}
+		})
+	}
+}
@@ -13,11 +13,12 @@ command_console() {
 }
 
 commands() {
-  for cmd in run create-config get-discussion version completion; do
+  for cmd in rename check plan apply moved "moved list" "moved prune" version completion; do
+    # shellcheck disable=SC2086
     echo "
 ## tfmv $cmd
 
-$(command_console tfmv help $cmd)"
+$(command_console tfmv $cmd --help)"
   done
 }
 
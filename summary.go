@@ -0,0 +1,173 @@
+package tfmv
+
+import (
+	"fmt"
+
+	"github.com/suzuki-shunsuke/tfmv/pkg/controller"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+	"github.com/suzuki-shunsuke/tfmv/pkg/verify"
+)
+
+// Summary is a summary of changes.
+type Summary struct {
+	// Changes is a list of changes sorted by directory, file, line, and address.
+	Changes []*Change `json:"changes"`
+	// SkippedDirs is a list of directories skipped in tolerant mode.
+	SkippedDirs []*SkippedDir `json:"skipped_dirs,omitempty"`
+	// SkippedTFMigrateFiles is a list of tfmigrate migration files which aren't written because they already exist.
+	SkippedTFMigrateFiles []string `json:"skipped_tfmigrate_files,omitempty"`
+}
+
+// Change is a change of a Terraform block in a summary.
+type Change struct {
+	// Dir is a Terraform module directory path.
+	Dir string `json:"dir"`
+	// File is a file path where the block is defined.
+	File string `json:"file"`
+	// Line is a line number where the block is defined.
+	Line int `json:"line"`
+	// BlockType is one of "resource", "data", or "module".
+	BlockType string `json:"block_type"`
+	// Address is a current Terraform address.
+	Address string `json:"address"`
+	// NewAddress is a new Terraform address.
+	NewAddress string `json:"new_address"`
+	// MovedFile is a file path where a moved block is written.
+	// Data sources don't have moved blocks, so this is empty.
+	MovedFile string `json:"moved_file,omitempty"`
+	// Rule is a name of the rule which renamed the block.
+	// This is empty if rules aren't used.
+	Rule string `json:"rule,omitempty"`
+	// MovedBlockWritten is true if a moved block is written.
+	// In dry-run mode, this is true if a moved block would be written.
+	MovedBlockWritten bool `json:"moved_block_written"`
+	// RefFiles is a list of files where references to the block are rewritten.
+	RefFiles []*RefFile `json:"ref_files"`
+	// UnfixedRefFiles is a list of files outside the reference scope where references to the block remain.
+	UnfixedRefFiles []*RefFile `json:"unfixed_ref_files,omitempty"`
+}
+
+// RefFile is a file where references to a block are rewritten.
+type RefFile struct {
+	// File is a file path.
+	File string `json:"file"`
+	// Count is the number of references.
+	Count int `json:"count"`
+}
+
+// SkippedDir is a directory skipped in tolerant mode.
+type SkippedDir struct {
+	// Dir is a directory path.
+	Dir string `json:"dir"`
+	// Diagnostics is a list of parse errors of files in the directory.
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// Diagnostic is a parse error of a file in a skipped directory.
+type Diagnostic struct {
+	// File is a file path.
+	File string `json:"file"`
+	// Line is a line number starting from 1.
+	// This is zero if the position is unknown.
+	Line int `json:"line,omitempty"`
+	// Column is a column number starting from 1.
+	// This is zero if the position is unknown.
+	Column int `json:"column,omitempty"`
+	// Summary is a short description of the error.
+	Summary string `json:"summary"`
+	// Detail is a detailed description of the error.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Issue is an issue found by the verification after applying changes.
+type Issue struct {
+	// File is a file path.
+	File string
+	// Line is a line number starting from 1.
+	Line int
+	// Column is a column number starting from 1.
+	Column int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a string like "main.tf:3:5: message".
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.File, i.Line, i.Column, i.Message)
+}
+
+// newSummary converts controller.Summary to Summary.
+func newSummary(s *controller.Summary) *Summary {
+	if s == nil {
+		return nil
+	}
+	summary := &Summary{
+		Changes:               make([]*Change, len(s.Changes)),
+		SkippedTFMigrateFiles: s.SkippedTFMigrateFiles,
+	}
+	for i, c := range s.Changes {
+		summary.Changes[i] = &Change{
+			Dir:               c.Dir,
+			File:              c.File,
+			Line:              c.Line,
+			BlockType:         c.BlockType,
+			Address:           c.Address,
+			NewAddress:        c.NewAddress,
+			MovedFile:         c.MovedFile,
+			Rule:              c.Rule,
+			MovedBlockWritten: c.MovedBlockWritten,
+			RefFiles:          newRefFiles(c.RefFiles),
+			UnfixedRefFiles:   newRefFiles(c.UnfixedRefFiles),
+		}
+	}
+	if len(s.SkippedDirs) != 0 {
+		summary.SkippedDirs = make([]*SkippedDir, len(s.SkippedDirs))
+	}
+	for i, d := range s.SkippedDirs {
+		dir := &SkippedDir{
+			Dir:         d.Dir,
+			Diagnostics: make([]*Diagnostic, len(d.Diagnostics)),
+		}
+		for j, diag := range d.Diagnostics {
+			dir.Diagnostics[j] = &Diagnostic{
+				File:    diag.File,
+				Line:    diag.Line,
+				Column:  diag.Column,
+				Summary: diag.Summary,
+				Detail:  diag.Detail,
+			}
+		}
+		summary.SkippedDirs[i] = dir
+	}
+	return summary
+}
+
+func newRefFiles(files []*domain.RefFile) []*RefFile {
+	if files == nil {
+		return nil
+	}
+	arr := make([]*RefFile, len(files))
+	for i, f := range files {
+		arr[i] = &RefFile{
+			File:  f.File,
+			Count: f.Count,
+		}
+	}
+	return arr
+}
+
+func newIssues(issues []*verify.Issue) []*Issue {
+	if issues == nil {
+		return nil
+	}
+	arr := make([]*Issue, len(issues))
+	for i, issue := range issues {
+		arr[i] = &Issue{
+			File:    issue.File,
+			Line:    issue.Line,
+			Column:  issue.Column,
+			Message: issue.Message,
+		}
+	}
+	return arr
+}
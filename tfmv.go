@@ -0,0 +1,360 @@
+// Package tfmv provides a Go API to rename Terraform resources, data sources, and modules and generate moved blocks.
+//
+// The API doesn't touch command line flags and stdout, and Terraform files are read and written through the given afero.Fs,
+// so it can be called repeatedly in one process and in parallel tests with in-memory file systems.
+// Note that some options depend on the current directory of the process rather than the file system:
+// ChangedSince runs git and Command runs the external command in the current directory of the process.
+package tfmv
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfmv/pkg/controller"
+	"github.com/suzuki-shunsuke/tfmv/pkg/domain"
+)
+
+// InputChange is a rename of a block given by a user.
+type InputChange struct {
+	// Dir is a Terraform module directory path.
+	Dir string `json:"dir"`
+	// Address is a current Terraform address.
+	Address string `json:"address"`
+	// NewAddress is a new Terraform address.
+	NewAddress string `json:"new_address"`
+}
+
+// Rule is a rename rule with its own renamer and scope.
+// Rules are evaluated in order and the first rule whose scope matches a block renames the block.
+type Rule struct {
+	// Name is a rule name. It is recorded in the summary.
+	// The default is "rules[<index>]".
+	Name string
+	// Replace, Regexp, Jsonnet, Starlark, and Command are renamers. One of them must be specified.
+	Replace  string
+	Regexp   string
+	Jsonnet  string
+	Starlark string
+	Command  string
+	// CommandTimeout is a timeout of each execution of Command.
+	// If this is zero, the default timeout 30s is used.
+	// If this is negative, the command never times out.
+	CommandTimeout time.Duration
+	// CommandBatch passes all blocks matching the rule to Command at once as JSON Lines.
+	CommandBatch bool
+	// Include is a regular expression of Terraform addresses the rule applies to.
+	Include string
+	// Exclude is a regular expression of Terraform addresses the rule doesn't apply to.
+	Exclude string
+	// Dirs is a list of glob patterns of directories the rule applies to.
+	// Patterns are relative to the root of the file system.
+	// A pattern also matches subdirectories of matching directories.
+	// If this is empty, the rule applies to all directories.
+	Dirs []string
+	// BlockTypes is a list of block types the rule applies to.
+	// If this is empty, the rule applies to all block types.
+	BlockTypes []string
+	// MovedFile is a file name where moved blocks are written.
+	// If this is empty, Options.MovedFile is used.
+	MovedFile string
+}
+
+var (
+	// ErrInvalidConfiguration is returned if the verification finds issues in the rewritten configuration.
+	ErrInvalidConfiguration = controller.ErrInvalidConfiguration
+	// ErrDirsSkipped is returned if directories are skipped in tolerant mode.
+	ErrDirsSkipped = controller.ErrDirsSkipped
+)
+
+// Options is options of Run.
+// One of Replace, Regexp, Jsonnet, Starlark, Command, Changes, or Rules must be specified.
+type Options struct {
+	// Replace replaces strings in block names. The format is <old>/<new>.
+	Replace string
+	// Regexp replaces strings in block names by a regular expression. The format is <regular expression>/<new>.
+	Regexp string
+	// Jsonnet is a Jsonnet file path.
+	Jsonnet string
+	// Starlark is a Starlark file path.
+	// The file must define a function rename(block).
+	Starlark string
+	// Command is an external command to rename blocks.
+	// Blocks are passed to the command's stdin as JSON and new names are read from the stdout.
+	Command string
+	// CommandTimeout is a timeout of each execution of Command.
+	// If this is zero, the default timeout 30s is used.
+	// If this is negative, the command never times out.
+	CommandTimeout time.Duration
+	// CommandBatch passes all blocks to Command at once as JSON Lines.
+	CommandBatch bool
+	// Changes is a list of renames.
+	Changes []*InputChange
+	// Rules is an ordered list of rename rules.
+	// The first rule whose scope matches a block renames the block.
+	Rules []*Rule
+	// Include is a regular expression to filter blocks.
+	Include string
+	// Exclude is a regular expression to filter blocks.
+	Exclude string
+	// MovedFile is a file name where moved blocks are written.
+	// The default is "moved.tf".
+	MovedFile string
+	// Emit is a list of outputs to migrate Terraform states.
+	// The default is "moved".
+	Emit []string
+	// StateMvFile is a file name of shell scripts of `terraform state mv` commands.
+	// The default is "tfmv_state_mv.sh".
+	StateMvFile string
+	// TFMigrateFile is a file name of tfmigrate migration files.
+	// The default is "tfmv_tfmigrate.hcl".
+	TFMigrateFile string
+	// Files is a list of Terraform files.
+	// If this is empty, *.tf in the current directory of the file system are used.
+	Files []string
+	// Recursive finds *.tf recursively.
+	Recursive bool
+	// RootModules is a list of root module directories.
+	// If this is set, the root modules and local modules called from them transitively are processed.
+	RootModules []string
+	// ChangedSince is a git ref.
+	// If this is set, only directories including *.tf changed since the ref are processed among files found as usual.
+	// git is run in the current directory of the process, not the file system.
+	ChangedSince string
+	// Ignore is a list of gitignore style patterns of files and directories ignored when Recursive is true.
+	// .gitignore and .tfmvignore are also respected.
+	Ignore []string
+	// DryRun doesn't change files.
+	DryRun bool
+	// Check only plans changes.
+	Check bool
+	// Tolerant skips directories including files which can't be parsed instead of failing.
+	// Skipped directories are returned in the summary with ErrDirsSkipped.
+	Tolerant bool
+	// RefScope is a scope where references to renamed blocks are fixed.
+	// "file", "dir", and "recursive" are available. The default is "dir".
+	RefScope string
+	// Parallelism is the maximum number of files, blocks, or directories processed concurrently.
+	// If this is zero, the number of CPUs is used.
+	Parallelism int
+	// Logger is a logger. If this is nil, logs are discarded.
+	Logger *slog.Logger
+	// Diff is a writer where a unified diff is written in dry-run mode.
+	// If this is nil, the diff is discarded.
+	Diff io.Writer
+}
+
+// Result is a result of Run.
+// Internal types of the planner aren't exposed, so Summary is the stable representation of changes.
+type Result struct {
+	// Summary is a summary of changes.
+	Summary *Summary
+	// Issues is a list of issues found by the verification after applying changes.
+	Issues []*Issue
+}
+
+// Run renames blocks in the file system fs and returns the result.
+// If opts.DryRun or opts.Check is true, fs isn't changed.
+// If the verification finds issues, Run returns both the result and ErrInvalidConfiguration.
+// If directories are skipped in tolerant mode, Run returns both the result and ErrDirsSkipped.
+func Run(fs afero.Fs, opts *Options) (*Result, error) {
+	input, err := opts.input()
+	if err != nil {
+		return nil, err
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	diff := opts.Diff
+	if diff == nil {
+		diff = io.Discard
+	}
+	ctrl := &controller.Controller{}
+	ctrl.Init(fs, io.Discard, diff)
+	result, err := ctrl.Exec(logger, input)
+	if result == nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &Result{
+		Summary: newSummary(result.Summary),
+		Issues:  newIssues(result.Issues),
+	}, err //nolint:wrapcheck
+}
+
+// StreamResult is a result of RunStream.
+type StreamResult struct {
+	Result
+	// Content is the rewritten content of the file.
+	Content []byte
+	// MovedBlocks is generated moved blocks.
+	// If opts.MovedFile is "same", moved blocks are appended to Content and this is empty.
+	MovedBlocks []byte
+}
+
+// RunStream renames blocks in src, the content of the file path, and returns the rewritten content.
+// The file needn't exist in fs, and fs isn't changed.
+// If path is empty, the content is handled as stdin.tf in the current directory, and then no *.tf may exist there.
+// Other files in the same directory are read to verify the result.
+// References in them aren't fixed but are reported in Summary as unfixed references.
+// opts.Files, opts.DryRun, opts.Check, and opts.RefScope are ignored.
+// If the verification finds issues, RunStream returns both the result and ErrInvalidConfiguration.
+func RunStream(fs afero.Fs, opts *Options, path string, src []byte) (*StreamResult, error) {
+	input, err := opts.input()
+	if err != nil {
+		return nil, err
+	}
+	input.DryRun = false
+	input.Check = false
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	ctrl := &controller.Controller{}
+	ctrl.Init(fs, io.Discard, io.Discard)
+	result, err := ctrl.ExecStream(logger, input, path, src)
+	if result == nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &StreamResult{
+		Result: Result{
+			Summary: newSummary(result.Summary),
+			Issues:  newIssues(result.Issues),
+		},
+		Content:     result.Content,
+		MovedBlocks: result.MovedBlocks,
+	}, err //nolint:wrapcheck
+}
+
+// input converts Options to domain.Input.
+func (o *Options) input() (*domain.Input, error) {
+	input := &domain.Input{
+		Replace:        o.Replace,
+		Regexp:         o.Regexp,
+		Jsonnet:        o.Jsonnet,
+		Starlark:       o.Starlark,
+		Command:        o.Command,
+		CommandTimeout: o.CommandTimeout,
+		CommandBatch:   o.CommandBatch,
+		Changes:        o.changes(),
+		MovedFile:      o.MovedFile,
+		Emit:           o.Emit,
+		StateMvFile:    o.StateMvFile,
+		TFMigrateFile:  o.TFMigrateFile,
+		Args:           o.Files,
+		Recursive:      o.Recursive,
+		Ignore:         o.Ignore,
+		ChangedSince:   o.ChangedSince,
+		RootModules:    o.RootModules,
+		DryRun:         o.DryRun,
+		Check:          o.Check,
+		Parallelism:    o.Parallelism,
+		RefScope:       o.RefScope,
+		Tolerant:       o.Tolerant,
+	}
+	if input.MovedFile == "" {
+		input.MovedFile = "moved.tf"
+	}
+	if input.StateMvFile == "" {
+		input.StateMvFile = "tfmv_state_mv.sh"
+	}
+	if input.TFMigrateFile == "" {
+		input.TFMigrateFile = "tfmv_tfmigrate.hcl"
+	}
+	if err := domain.ValidateMovedFile(input.MovedFile); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := domain.ValidateEmit(input.Emit); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := domain.ValidateFileName("StateMvFile", input.StateMvFile); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := domain.ValidateFileName("TFMigrateFile", input.TFMigrateFile); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := domain.ValidateRefScope(input.RefScope); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if o.Include != "" {
+		r, err := regexp.Compile(o.Include)
+		if err != nil {
+			return nil, fmt.Errorf("the include option is an invalid regular expression: %w", err)
+		}
+		input.Include = r
+	}
+	if o.Exclude != "" {
+		r, err := regexp.Compile(o.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("the exclude option is an invalid regular expression: %w", err)
+		}
+		input.Exclude = r
+	}
+	rules, err := o.rules()
+	if err != nil {
+		return nil, err
+	}
+	input.Rules = rules
+	return input, nil
+}
+
+// changes converts Changes to domain.Change.
+func (o *Options) changes() []*domain.Change {
+	if o.Changes == nil {
+		return nil
+	}
+	changes := make([]*domain.Change, len(o.Changes))
+	for i, c := range o.Changes {
+		changes[i] = &domain.Change{
+			Dir:        c.Dir,
+			Address:    c.Address,
+			NewAddress: c.NewAddress,
+		}
+	}
+	return changes
+}
+
+// rules converts Rules to domain.Rule.
+func (o *Options) rules() ([]*domain.Rule, error) {
+	if o.Rules == nil {
+		return nil, nil
+	}
+	rules := make([]*domain.Rule, len(o.Rules))
+	for i, r := range o.Rules {
+		rule := &domain.Rule{
+			Name:           cmp.Or(r.Name, "rules["+strconv.Itoa(i)+"]"),
+			Replace:        r.Replace,
+			Regexp:         r.Regexp,
+			Jsonnet:        r.Jsonnet,
+			Starlark:       r.Starlark,
+			Command:        r.Command,
+			CommandTimeout: r.CommandTimeout,
+			CommandBatch:   r.CommandBatch,
+			Dirs:           r.Dirs,
+			BlockTypes:     r.BlockTypes,
+			MovedFile:      r.MovedFile,
+		}
+		if r.Include != "" {
+			include, err := regexp.Compile(r.Include)
+			if err != nil {
+				return nil, fmt.Errorf("the include option of a rule is an invalid regular expression: %w", slogerr.With(err, "rule", rule.Name))
+			}
+			rule.Include = include
+		}
+		if r.Exclude != "" {
+			exclude, err := regexp.Compile(r.Exclude)
+			if err != nil {
+				return nil, fmt.Errorf("the exclude option of a rule is an invalid regular expression: %w", slogerr.With(err, "rule", rule.Name))
+			}
+			rule.Exclude = exclude
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
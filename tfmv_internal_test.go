@@ -0,0 +1,793 @@
+package tfmv_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfmv"
+)
+
+func TestRun(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	tests := []struct {
+		name     string
+		files    map[string]string
+		opts     *tfmv.Options
+		expFiles map[string]string
+		changes  int
+		unfixed  int
+		skipped  int
+		isErr    bool
+	}{
+		{
+			name: "replace",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+output "id" {
+  value = null_resource.foo-1.id
+}
+`,
+			},
+			opts: &tfmv.Options{
+				Replace: "-/_",
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+output "id" {
+  value = null_resource.foo_1.id
+}
+`,
+				"moved.tf": `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			},
+			changes: 1,
+		},
+		{
+			name: "dry run",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}
+`,
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				Recursive: true,
+				DryRun:    true,
+			},
+			expFiles: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}
+`,
+			},
+			changes: 1,
+		},
+		{
+			name: "command",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+			},
+			opts: &tfmv.Options{
+				Command: `sh -c 'sed -E "s/.*\"name\":\"([^\"]*)\".*/\"\\1\"/; s/-/_/g"'`,
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+`,
+			},
+			changes: 1,
+		},
+		{
+			name: "command batch",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+resource "null_resource" "bar" {}
+
+resource "null_resource" "baz-1" {}
+`,
+			},
+			opts: &tfmv.Options{
+				Command:      `sh -c 'sed -E "s/.*\"name\":\"([^\"]*)\".*/\"\\1\"/; s/-/_/g"'`,
+				CommandBatch: true,
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+resource "null_resource" "bar" {}
+
+resource "null_resource" "baz_1" {}
+`,
+			},
+			changes: 2,
+		},
+		{
+			name: "command fails",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+			},
+			opts: &tfmv.Options{
+				Command: "false",
+			},
+			isErr: true,
+		},
+		{
+			name: "starlark",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+module "bar-1" {
+  source = "./bar"
+}
+`,
+				"rename.star": `def rename(block):
+    if block.block_type == "module":
+        return None
+    before, after, found = strings.Cut(block.name, "-")
+    if not found:
+        return None
+    return before + "_" + after
+`,
+			},
+			opts: &tfmv.Options{
+				Starlark: "rename.star",
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+module "bar-1" {
+  source = "./bar"
+}
+`,
+			},
+			changes: 1,
+		},
+		{
+			name: "starlark step limit",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"rename.star": `def rename(block):
+    for i in range(100000000):
+        pass
+    return "foo"
+`,
+			},
+			opts: &tfmv.Options{
+				Starlark: "rename.star",
+			},
+			isErr: true,
+		},
+		{
+			name: "rules",
+			files: map[string]string{
+				"modules/foo/main.tf": `module "foo-1" {
+  source = "./foo"
+}
+
+resource "aws_iam_role" "legacy_admin" {}
+`,
+				"iam/main.tf": `resource "aws_iam_role" "legacy_admin" {}
+
+resource "aws_iam_role" "legacy-readonly" {}
+`,
+			},
+			opts: &tfmv.Options{
+				Recursive: true,
+				Rules: []*tfmv.Rule{
+					{
+						Name:       "modules",
+						Replace:    "-/_",
+						Dirs:       []string{"modules"},
+						BlockTypes: []string{"module"},
+						MovedFile:  "moved_modules.tf",
+					},
+					{
+						Name:    "legacy-iam",
+						Regexp:  "^legacy[-_]/",
+						Include: `^aws_iam_`,
+						Dirs:    []string{"iam"},
+					},
+				},
+			},
+			expFiles: map[string]string{
+				"modules/foo/main.tf": `module "foo_1" {
+  source = "./foo"
+}
+
+resource "aws_iam_role" "legacy_admin" {}
+`,
+				"modules/foo/moved_modules.tf": `moved {
+  from = module.foo-1
+  to   = module.foo_1
+}
+`,
+				"iam/main.tf": `resource "aws_iam_role" "admin" {}
+
+resource "aws_iam_role" "readonly" {}
+`,
+			},
+			changes: 3,
+		},
+		{
+			name: "ignore",
+			files: map[string]string{
+				".gitignore":            "vendor/\n*.generated.tf\n!keep.generated.tf\n",
+				"foo/main.tf":           `resource "null_resource" "foo-1" {}` + "\n",
+				"foo/.tfmvignore":       "old\n",
+				"foo/old/main.tf":       `resource "null_resource" "foo-2" {}` + "\n",
+				"vendor/main.tf":        `resource "null_resource" "foo-3" {}` + "\n",
+				"bar/main.generated.tf": `resource "null_resource" "foo-4" {}` + "\n",
+				"bar/keep.generated.tf": `resource "null_resource" "foo-5" {}` + "\n",
+				"baz/main.tf":           `resource "null_resource" "foo-6" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				Recursive: true,
+				Ignore:    []string{"/baz"},
+			},
+			expFiles: map[string]string{
+				"foo/old/main.tf": `resource "null_resource" "foo-2" {}` + "\n",
+				"vendor/main.tf":  `resource "null_resource" "foo-3" {}` + "\n",
+			},
+			changes: 2,
+		},
+		{
+			name: "root modules",
+			files: map[string]string{
+				"envs/prod/main.tf": `module "app" {
+  source = "../../modules/app"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`,
+				"modules/app/main.tf": `module "network" {
+  source = "../network"
+}
+
+resource "null_resource" "foo-1" {}
+`,
+				"modules/network/main.tf": `module "app" {
+  source = "./../app"
+}
+
+resource "null_resource" "foo-2" {}
+`,
+				"other/main.tf": `resource "null_resource" "foo-3" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:     "-/_",
+				RootModules: []string{"envs/prod"},
+			},
+			expFiles: map[string]string{
+				"other/main.tf": `resource "null_resource" "foo-3" {}` + "\n",
+			},
+			changes: 2,
+		},
+		{
+			name: "root module not found",
+			opts: &tfmv.Options{
+				Replace:     "-/_",
+				RootModules: []string{"envs/dev"},
+			},
+			isErr: true,
+		},
+		{
+			name: "parallelism",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+				"foo/ref.tf":  `output "id" { value = null_resource.foo-1.id }` + "\n",
+				"bar/main.tf": `resource "null_resource" "bar-1" {}` + "\n",
+				"baz/main.tf": `resource "null_resource" "baz-1" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:     "-/_",
+				Recursive:   true,
+				Parallelism: 3,
+			},
+			expFiles: map[string]string{
+				"foo/ref.tf": `output "id" { value = null_resource.foo_1.id }` + "\n",
+				"bar/moved.tf": `moved {
+  from = null_resource.bar-1
+  to   = null_resource.bar_1
+}
+`,
+			},
+			changes: 3,
+		},
+		{
+			name: "ref scope dir",
+			files: map[string]string{
+				"main.tf":    `resource "null_resource" "foo-1" {}` + "\n",
+				"output.tf":  `output "id" { value = null_resource.foo-1.id }` + "\n",
+				"sub/foo.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace: "-/_",
+				Files:   []string{"main.tf"},
+			},
+			expFiles: map[string]string{
+				"output.tf":  `output "id" { value = null_resource.foo_1.id }` + "\n",
+				"sub/foo.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			changes: 1,
+			unfixed: 1,
+		},
+		{
+			name: "ref scope file",
+			files: map[string]string{
+				"main.tf":   `resource "null_resource" "foo-1" {}` + "\n",
+				"output.tf": `output "id" { value = null_resource.foo-1.id }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:  "-/_",
+				Files:    []string{"main.tf"},
+				RefScope: "file",
+			},
+			// the remaining reference is found by the verification
+			isErr: true,
+		},
+		{
+			name: "ref scope recursive",
+			files: map[string]string{
+				"main.tf":    `resource "null_resource" "foo-1" {}` + "\n",
+				"sub/foo.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:  "-/_",
+				RefScope: "recursive",
+			},
+			expFiles: map[string]string{
+				"sub/foo.tf": `locals { id = "null_resource.foo_1" }` + "\n",
+			},
+			changes: 1,
+		},
+		{
+			name: "ref scope recursive excludes child modules",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+				// the same address means the child module's own resource
+				"child/main.tf": `resource "null_resource" "foo-1" {}
+
+output "id" { value = null_resource.foo-1.id }
+`,
+				"child/sub/foo.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:  "-/_",
+				Files:    []string{"main.tf"},
+				RefScope: "recursive",
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}` + "\n",
+				"child/main.tf": `resource "null_resource" "foo-1" {}
+
+output "id" { value = null_resource.foo-1.id }
+`,
+				"child/sub/foo.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			changes: 1,
+		},
+		{
+			name: "ref scope recursive excludes ignored directories",
+			files: map[string]string{
+				".gitignore":     "vendor/\n",
+				"main.tf":        `resource "null_resource" "foo-1" {}` + "\n",
+				"vendor/foo.tf":  `locals { id = "null_resource.foo-1" }` + "\n",
+				"generated/a.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+				"docs/a.tf":      `locals { id = "null_resource.foo-1" }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:  "-/_",
+				Files:    []string{"main.tf"},
+				RefScope: "recursive",
+				Ignore:   []string{"generated"},
+			},
+			expFiles: map[string]string{
+				"vendor/foo.tf":  `locals { id = "null_resource.foo-1" }` + "\n",
+				"generated/a.tf": `locals { id = "null_resource.foo-1" }` + "\n",
+				"docs/a.tf":      `locals { id = "null_resource.foo_1" }` + "\n",
+			},
+			changes: 1,
+		},
+		{
+			name: "ref scope recursive in recursive mode",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+
+output "id" { value = null_resource.foo-1.id }
+`,
+				"child/main.tf": `resource "null_resource" "bar-1" {}
+
+output "id" { value = null_resource.bar-1.id }
+`,
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				Recursive: true,
+				RefScope:  "recursive",
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+
+output "id" { value = null_resource.foo_1.id }
+`,
+				"child/main.tf": `resource "null_resource" "bar_1" {}
+
+output "id" { value = null_resource.bar_1.id }
+`,
+			},
+			changes: 2,
+		},
+		{
+			name: "invalid ref scope",
+			opts: &tfmv.Options{
+				Replace:  "-/_",
+				RefScope: "repo",
+			},
+			isErr: true,
+		},
+		{
+			name: "invalid include of a rule",
+			opts: &tfmv.Options{
+				Rules: []*tfmv.Rule{
+					{
+						Replace: "-/_",
+						Include: "(",
+					},
+				},
+			},
+			isErr: true,
+		},
+		{
+			name: "swap names",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo" {}
+
+resource "null_resource" "bar" {}
+
+output "foo" {
+  value = null_resource.foo.id
+}
+`,
+			},
+			opts: &tfmv.Options{
+				Changes: []*tfmv.InputChange{
+					{Dir: ".", Address: "null_resource.foo", NewAddress: "null_resource.bar"},
+					{Dir: ".", Address: "null_resource.bar", NewAddress: "null_resource.foo"},
+				},
+				Emit: []string{"state-mv"},
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "bar" {}
+
+resource "null_resource" "foo" {}
+
+output "foo" {
+  value = null_resource.bar.id
+}
+`,
+			},
+			changes: 2,
+		},
+		{
+			name: "tolerant",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"bar/main.tf": `resource "null_resource" "bar-1" {}
+`,
+				"bar/broken.tf": `resource "null_resource" "bar-2" {
+`,
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				Recursive: true,
+				Tolerant:  true,
+			},
+			expFiles: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo_1" {}
+`,
+				"bar/main.tf": `resource "null_resource" "bar-1" {}
+`,
+			},
+			changes: 1,
+			skipped: 1,
+		},
+		{
+			name: "tolerant recursive reference scope",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"child/main.tf": `output "id" { value = null_resource.foo-1.id }
+`,
+				"child/broken.tf": `resource "null_resource" "bar-1" {
+`,
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				Recursive: true,
+				Tolerant:  true,
+				RefScope:  "recursive",
+			},
+			expFiles: map[string]string{
+				"main.tf": `resource "null_resource" "foo_1" {}
+`,
+				// files in the skipped directory aren't changed
+				"child/main.tf": `output "id" { value = null_resource.foo-1.id }
+`,
+			},
+			changes: 1,
+			skipped: 1,
+		},
+		{
+			name: "tolerant root modules",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}
+`,
+				"bar/main.tf": `module "bar-1" {
+  source = "../modules/bar"
+}
+`,
+				"bar/broken.tf": `resource "null_resource" "bar-2" {
+`,
+			},
+			opts: &tfmv.Options{
+				Replace:     "-/_",
+				RootModules: []string{"foo", "bar"},
+				Tolerant:    true,
+			},
+			expFiles: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo_1" {}
+`,
+				"bar/main.tf": `module "bar-1" {
+  source = "../modules/bar"
+}
+`,
+			},
+			changes: 1,
+			skipped: 1,
+		},
+		{
+			name: "not tolerant",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {
+`,
+			},
+			opts: &tfmv.Options{
+				Replace: "-/_",
+			},
+			isErr: true,
+		},
+		{
+			name: "invalid moved file",
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				MovedFile: "moved.txt",
+			},
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result, err := tfmv.Run(fs, tt.opts)
+			if err != nil && (tt.skipped == 0 || !errors.Is(err, tfmv.ErrDirsSkipped)) {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			if len(result.Summary.SkippedDirs) != tt.skipped {
+				t.Fatalf("wanted %d skipped directories, got %d", tt.skipped, len(result.Summary.SkippedDirs))
+			}
+			if len(result.Summary.Changes) != tt.changes {
+				t.Fatalf("wanted %d changes, got %d", tt.changes, len(result.Summary.Changes))
+			}
+			unfixed := 0
+			for _, change := range result.Summary.Changes {
+				unfixed += len(change.UnfixedRefFiles)
+			}
+			if unfixed != tt.unfixed {
+				t.Fatalf("wanted %d unfixed reference files, got %d", tt.unfixed, unfixed)
+			}
+			for path, exp := range tt.expFiles {
+				b, err := afero.ReadFile(fs, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != exp {
+					t.Fatalf("%s: wanted %q, got %q", path, exp, string(b))
+				}
+			}
+		})
+	}
+}
+
+func TestRunStream(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	tests := []struct {
+		name    string
+		files   map[string]string
+		opts    *tfmv.Options
+		path    string
+		src     string
+		content string
+		moved   string
+		// unfixed is expected files where references remain, formatted as "<file>=<count>".
+		unfixed  []string
+		expFiles map[string]string
+		isErr    bool
+	}{
+		{
+			name: "append moved blocks",
+			files: map[string]string{
+				"foo/output.tf": `output "id" { value = null_resource.foo-1.id }` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				MovedFile: "same",
+			},
+			path: filepath.Join("foo", "main.tf"),
+			src:  `resource "null_resource" "foo-1" {}` + "\n",
+			content: `resource "null_resource" "foo_1" {}
+
+moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			unfixed: []string{filepath.Join("foo", "output.tf") + "=1"},
+			expFiles: map[string]string{
+				"foo/output.tf": `output "id" { value = null_resource.foo-1.id }` + "\n",
+			},
+		},
+		{
+			name: "separate moved blocks",
+			files: map[string]string{
+				"foo/moved.tf": "# moved blocks\n",
+			},
+			opts: &tfmv.Options{
+				Replace: "-/_",
+			},
+			path:    filepath.Join("foo", "main.tf"),
+			src:     `resource "null_resource" "foo-1" {}` + "\n",
+			content: `resource "null_resource" "foo_1" {}` + "\n",
+			moved: `moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			expFiles: map[string]string{
+				"foo/moved.tf": "# moved blocks\n",
+			},
+		},
+		{
+			name: "the file exists",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				MovedFile: "same",
+			},
+			path: filepath.Join("foo", "main.tf"),
+			src:  `resource "null_resource" "foo-1" {}` + "\n",
+			content: `resource "null_resource" "foo_1" {}
+
+moved {
+  from = null_resource.foo-1
+  to   = null_resource.foo_1
+}
+`,
+			expFiles: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+			},
+		},
+		{
+			name: "default path",
+			files: map[string]string{
+				"foo/main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				MovedFile: "same",
+			},
+			src: `resource "null_resource" "bar-1" {}` + "\n",
+			content: `resource "null_resource" "bar_1" {}
+
+moved {
+  from = null_resource.bar-1
+  to   = null_resource.bar_1
+}
+`,
+		},
+		{
+			name: "default path with other files",
+			files: map[string]string{
+				"main.tf": `resource "null_resource" "foo-1" {}` + "\n",
+			},
+			opts: &tfmv.Options{
+				Replace:   "-/_",
+				MovedFile: "same",
+			},
+			src:   `resource "null_resource" "foo-1" {}` + "\n",
+			isErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result, err := tfmv.RunStream(fs, tt.opts, tt.path, []byte(tt.src))
+			if err != nil {
+				if tt.isErr {
+					return
+				}
+				t.Fatal(err)
+			}
+			if tt.isErr {
+				t.Fatal("error is expected")
+			}
+			if string(result.Content) != tt.content {
+				t.Fatalf("content: wanted %q, got %q", tt.content, string(result.Content))
+			}
+			if string(result.MovedBlocks) != tt.moved {
+				t.Fatalf("moved blocks: wanted %q, got %q", tt.moved, string(result.MovedBlocks))
+			}
+			unfixed := []string{}
+			for _, change := range result.Summary.Changes {
+				for _, f := range change.UnfixedRefFiles {
+					unfixed = append(unfixed, fmt.Sprintf("%s=%d", f.File, f.Count))
+				}
+			}
+			if !slices.Equal(unfixed, tt.unfixed) {
+				t.Fatalf("unfixed references: wanted %v, got %v", tt.unfixed, unfixed)
+			}
+			if _, ok := tt.files[tt.path]; !ok {
+				path := tt.path
+				if path == "" {
+					path = "stdin.tf"
+				}
+				if ok, err := afero.Exists(fs, path); err != nil || ok {
+					t.Fatal("the file system must not be changed")
+				}
+			}
+			for path, exp := range tt.expFiles {
+				b, err := afero.ReadFile(fs, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != exp {
+					t.Fatalf("%s: wanted %q, got %q", path, exp, string(b))
+				}
+			}
+		})
+	}
+}